@@ -10,6 +10,7 @@ require (
 	github.com/containeroo/tinyflags v0.0.80
 	github.com/prometheus/client_golang v1.24.1
 	github.com/stretchr/testify v1.12.1
+	golang.org/x/crypto v0.50.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"io/fs"
@@ -9,7 +10,9 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/containeroo/heartbeats/internal/auth"
 	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/digest"
 	"github.com/containeroo/heartbeats/internal/flag"
 	"github.com/containeroo/heartbeats/internal/handler"
 	"github.com/containeroo/heartbeats/internal/heartbeat/manager"
@@ -19,16 +22,22 @@ import (
 	"github.com/containeroo/heartbeats/internal/logging"
 	"github.com/containeroo/heartbeats/internal/metrics"
 	appnotify "github.com/containeroo/heartbeats/internal/notify"
+	"github.com/containeroo/heartbeats/internal/outbox"
+	"github.com/containeroo/heartbeats/internal/overlay"
 	"github.com/containeroo/heartbeats/internal/routes"
+	"github.com/containeroo/heartbeats/internal/tlsserver"
 	"github.com/containeroo/heartbeats/internal/ws"
 
 	"github.com/containeroo/httpgrace/server"
-	kit "github.com/containeroo/notifykit/notify"
 	"github.com/containeroo/tinyflags"
 )
 
 // Run is the single entry point for the application.
 func Run(ctx context.Context, appFS fs.FS, version, commit string, args []string, w io.Writer) error {
+	if len(args) > 0 && args[0] == "validate" {
+		return Validate(appFS, version, args[1:], w)
+	}
+
 	// Create a context to listen for shutdown signals
 	// Cancel on SIGINT/SIGTERM for graceful shutdown.
 	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
@@ -65,7 +74,18 @@ func Run(ctx context.Context, appFS fs.FS, version, commit string, args []string
 	businessLogger := logging.BusinessLogger(logger)
 	accessLogger := logging.AccessLogger(logger)
 
-	cfg, err := config.LoadWithOptions(flags.ConfigPath, config.LoadOptions{StrictEnv: flags.StrictEnv})
+	overlayStore, err := overlay.NewStore(flags.OverlayPath)
+	if err != nil {
+		sysLogger.Error("application failed",
+			"event", "app_failed",
+			"stage", "load_overlay",
+			"err", err,
+		)
+		return err
+	}
+	loadOpts := config.LoadOptions{StrictEnv: flags.StrictEnv, Overlay: overlayStore}
+
+	cfg, err := config.LoadWithOptions(flags.ConfigPath, loadOpts)
 	if err != nil {
 		sysLogger.Error("application failed",
 			"event", "app_failed",
@@ -82,6 +102,17 @@ func Run(ctx context.Context, appFS fs.FS, version, commit string, args []string
 		accessLogger,
 	)
 
+	authn, err := auth.New(cfg.Auth)
+	if err != nil {
+		sysLogger.Error("application failed",
+			"event", "app_failed",
+			"stage", "build_auth",
+			"err", err,
+		)
+		return err
+	}
+	api.SetAuthenticator(authn)
+
 	metricsReg := metrics.NewRegistry()
 	api.SetMetrics(metricsReg)
 
@@ -90,7 +121,7 @@ func Run(ctx context.Context, appFS fs.FS, version, commit string, args []string
 	historyRecorder.Start(ctx)
 	api.SetHistory(historyRecorder)
 
-	templateFS, err := fs.Sub(appFS, "templates")
+	builtins, err := fs.Sub(appFS, "templates")
 	if err != nil {
 		sysLogger.Error("application failed",
 			"event", "app_failed",
@@ -99,6 +130,7 @@ func Run(ctx context.Context, appFS fs.FS, version, commit string, args []string
 		)
 		return err
 	}
+	templateFS := appnotify.TemplateFS(builtins, flags.TemplateDir)
 
 	receivers, receiverRoutes, err := appnotify.ReceiversFromConfig(templateFS, cfg, businessLogger)
 	if err != nil {
@@ -109,25 +141,20 @@ func Run(ctx context.Context, appFS fs.FS, version, commit string, args []string
 		)
 		return err
 	}
-	notifyManager, err := kit.NewManager(receivers, businessLogger)
+	queue, err := outbox.New(flags.QueuePath, receivers, historyRecorder, businessLogger)
 	if err != nil {
 		sysLogger.Error("application failed",
 			"event", "app_failed",
-			"stage", "build_notify_manager",
-			"err", err,
-		)
-		return err
-	}
-	if err := notifyManager.Start(ctx); err != nil {
-		sysLogger.Error("application failed",
-			"event", "app_failed",
-			"stage", "start_notify_manager",
+			"stage", "load_notification_queue",
 			"err", err,
 		)
 		return err
 	}
+	queue.SetConfig(cfg)
+	queue.Start(ctx)
+	api.SetNotificationQueue(queue)
 
-	manager, err := manager.NewManager(cfg, notifyManager, receiverRoutes, historyRecorder, metricsReg, businessLogger)
+	manager, err := manager.NewManager(cfg, queue, receiverRoutes, historyRecorder, metricsReg, businessLogger)
 	if err != nil {
 		sysLogger.Error("application failed",
 			"event", "app_failed",
@@ -138,21 +165,65 @@ func Run(ctx context.Context, appFS fs.FS, version, commit string, args []string
 	}
 	manager.StartAll(ctx)
 
-	svc := service.NewService(manager, notifyManager, historyRecorder, metricsReg)
+	svc := service.NewService(manager, queue, historyRecorder, metricsReg)
+	svc.SetProvisioner(overlayStore)
+	svc.SetAutoProvision(cfg)
 	api.SetService(svc)
 
-	reloadConfigFn := reconcile.NewReloadFunc(
+	digests := digest.NewScheduler(templateFS, manager, historyRecorder, queue, businessLogger)
+	digests.SetDigests(cfg.Digests)
+	digests.Start(ctx)
+
+	reloader := reconcile.NewReloader(
 		ctx,
 		flags.ConfigPath,
 		templateFS,
 		receivers,
 		receiverRoutes,
-		config.LoadOptions{StrictEnv: flags.StrictEnv},
+		loadOpts,
 		sysLogger,
 		manager,
+		cfg,
+		overlayStore.SetConfig,
+		svc.SetAutoProvision,
+		func(cfg *config.Config) { digests.SetDigests(cfg.Digests) },
+		func(cfg *config.Config) { metricsReg.PrunePayloadValues(payloadMetrics(cfg)) },
+		queue.SetConfig,
 	)
+	// The queue reads receivers from the active generation, so notifications
+	// of heartbeats started by a reload always find their receivers.
+	queue.SetReceiverSource(reloader.Receivers)
+	reloader.AddStage(func(cfg *config.Config) (func(), error) {
+		commit, err := authn.Prepare(cfg.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("auth: %w", err)
+		}
+		return commit, nil
+	})
+	reloadConfigFn := reloader.Apply
 	go reconcile.WatchReload(ctx, reloadCh, sysLogger, reloadConfigFn)
-	api.SetReloadFn(reloadConfigFn)
+	if flags.WatchConfig {
+		go reconcile.WatchConfig(ctx, flags.ConfigPath, flags.TemplateDir, sysLogger, reloadConfigFn)
+	}
+	api.SetReloadFn(reloader.Reload)
+	api.SetRollbackFn(reloader.Rollback)
+	api.SetGenerationFn(func() (uint64, string) {
+		gen := reloader.Generation()
+		return gen.Number, gen.Hash
+	})
+	api.SetReceiverTestFn(func(ctx context.Context, name string) ([]appnotify.TargetResult, error) {
+		return appnotify.SendTest(ctx, templateFS, reloader.Config(), name, businessLogger)
+	})
+	api.SetTemplateRenderFn(func(req appnotify.RenderRequest) (string, error) {
+		return appnotify.Render(templateFS, reloader.Config(), req)
+	})
+	overlayStore.SetConfig(cfg)
+	overlayStore.SetValidateFn(func(cfg *config.Config) error {
+		_, _, err := appnotify.ReceiversFromConfig(templateFS, cfg, businessLogger)
+		return err
+	})
+	overlayStore.SetApplyFn(reloadConfigFn)
+	api.SetOverlay(overlayStore)
 
 	wsHub := ws.NewHub(accessLogger, ws.Providers{
 		Heartbeats:    svc.HeartbeatSummaries,
@@ -174,7 +245,23 @@ func Run(ctx context.Context, appFS fs.FS, version, commit string, args []string
 		)
 		return err
 	}
-	if err := server.Run(ctx, flags.ListenAddr, router, sysLogger); err != nil {
+	serve := func() error { return server.Run(ctx, flags.ListenAddr, router, sysLogger) }
+	if flags.TLSCertFile != "" {
+		reloader, err := tlsserver.NewReloader(tlsOptions(flags), sysLogger)
+		if err != nil {
+			sysLogger.Error("application failed",
+				"event", "app_failed",
+				"stage", "load_tls",
+				"err", err,
+			)
+			return err
+		}
+		go reloader.Watch(ctx, tlsserver.DefaultReloadInterval)
+		serve = func() error {
+			return tlsserver.Run(ctx, flags.ListenAddr, router, reloader.TLSConfig(), sysLogger)
+		}
+	}
+	if err := serve(); err != nil {
 		sysLogger.Error("application failed",
 			"event", "app_failed",
 			"stage", "run_server",
@@ -185,3 +272,28 @@ func Run(ctx context.Context, appFS fs.FS, version, commit string, args []string
 
 	return nil
 }
+
+// tlsOptions maps the TLS flags to server options.
+func tlsOptions(flags flag.Options) tlsserver.Options {
+	clientAuth := tls.RequireAndVerifyClientCert
+	if flags.TLSClientAuth == flag.TLSClientAuthOptional {
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsserver.Options{
+		CertFile:     flags.TLSCertFile,
+		KeyFile:      flags.TLSKeyFile,
+		ClientCAFile: flags.TLSClientCAFile,
+		ClientAuth:   clientAuth,
+	}
+}
+
+// payloadMetrics returns the payload metric names of each heartbeat in cfg.
+func payloadMetrics(cfg *config.Config) map[string][]string {
+	out := make(map[string][]string, len(cfg.Heartbeats))
+	for id, hb := range cfg.Heartbeats {
+		for _, metric := range hb.Metrics {
+			out[id] = append(out[id], metric.Name)
+		}
+	}
+	return out
+}
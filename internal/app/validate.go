@@ -0,0 +1,83 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/flag"
+	appnotify "github.com/containeroo/heartbeats/internal/notify"
+	"github.com/containeroo/heartbeats/internal/overlay"
+
+	"github.com/containeroo/tinyflags"
+)
+
+// Validate checks a config without starting the server. It loads the config
+// and builds the receivers, including their templates, like a reload does,
+// prints every problem to w, and returns an error when the config is invalid.
+func Validate(appFS fs.FS, version string, args []string, w io.Writer) error {
+	flags, err := flag.ParseValidateFlags(args, version)
+	if err != nil {
+		if tinyflags.IsHelpRequested(err) || tinyflags.IsVersionRequested(err) {
+			fmt.Fprint(w, err.Error()) // nolint:errcheck
+			return nil
+		}
+		fmt.Fprintln(w, err) // nolint:errcheck
+		return err
+	}
+
+	cfg, err := loadForValidation(appFS, flags)
+	if err != nil {
+		fmt.Fprintln(w, "config is invalid:") // nolint:errcheck
+		for _, problem := range problems(err) {
+			fmt.Fprintf(w, "  - %s\n", problem) // nolint:errcheck
+		}
+		return err
+	}
+	fmt.Fprintf(w, "config is valid: %d heartbeats, %d receivers, %d digests\n", // nolint:errcheck
+		len(cfg.Heartbeats), len(cfg.Receivers), len(cfg.Digests))
+	return nil
+}
+
+// loadForValidation loads the config and reports config and receiver errors together.
+func loadForValidation(appFS fs.FS, flags flag.ValidateOptions) (*config.Config, error) {
+	overlayStore, err := overlay.NewStore(flags.OverlayPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.LoadWithOptions(flags.ConfigPath, config.LoadOptions{
+		StrictEnv:  flags.StrictEnv,
+		Overlay:    overlayStore,
+		NoValidate: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	builtins, err := fs.Sub(appFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+	templateFS := appnotify.TemplateFS(builtins, flags.TemplateDir)
+	_, _, receiversErr := appnotify.ReceiversFromConfig(templateFS, cfg, slog.New(slog.DiscardHandler))
+	if err := errors.Join(cfg.Validate(), receiversErr); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// problems splits a joined error into its distinct lines. Config validation
+// and building receivers both report unknown receivers; duplicates are dropped.
+func problems(err error) []string {
+	var out []string
+	for line := range strings.SplitSeq(err.Error(), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !slices.Contains(out, line) {
+			out = append(out, line)
+		}
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	appFS := os.DirFS("../..") // repository root with the built-in templates
+
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		path := write(t, `
+receivers:
+  ops:
+    webhooks:
+      - url: https://example.com
+heartbeats:
+  api:
+    interval: 1m
+    late_after: 30s
+    receivers: ["ops"]
+`)
+		var out strings.Builder
+		require.NoError(t, Validate(appFS, "test", []string{"--config", path}, &out))
+		assert.Equal(t, "config is valid: 1 heartbeats, 1 receivers, 0 digests\n", out.String())
+	})
+
+	t.Run("prints every problem", func(t *testing.T) {
+		t.Parallel()
+		path := write(t, `
+receivers:
+  ops:
+    webhooks:
+      - url: https://example.com
+        subject_override_tmpl: "{{ .Missing"
+heartbeats:
+  api:
+    interval: 1m
+    receivers: ["ops"]
+  backup:
+    interval: 1h
+    late_after: 5m
+    receivers: ["pager"]
+`)
+		var out strings.Builder
+		require.Error(t, Validate(appFS, "test", []string{"--config", path}, &out))
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 4, out.String())
+		assert.Equal(t, "config is invalid:", lines[0])
+		assert.Contains(t, lines[1], `heartbeat "api" late_after must be > 0`)
+		assert.Contains(t, lines[2], `heartbeat "backup" references unknown receiver "pager"`)
+		assert.Contains(t, lines[3], `heartbeat "api" receiver "ops"`)
+	})
+
+	t.Run("unreadable config", func(t *testing.T) {
+		t.Parallel()
+		var out strings.Builder
+		require.Error(t, Validate(appFS, "test", []string{"--config", filepath.Join(t.TempDir(), "missing.yaml")}, &out))
+		assert.Contains(t, out.String(), "read config")
+	})
+}
@@ -0,0 +1,360 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/containeroo/heartbeats/internal/config"
+)
+
+// Role grants access to a set of operations.
+type Role int
+
+const (
+	// RoleNone grants nothing.
+	RoleNone Role = iota
+	// RoleViewer may read the dashboard and API.
+	RoleViewer
+	// RoleOperator may additionally reload and change runtime state.
+	RoleOperator
+	// RoleAdmin may additionally change configuration.
+	RoleAdmin
+)
+
+// String returns the role identifier.
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleOperator:
+		return "operator"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// ParseRole parses a configured role name. An empty name means viewer.
+func ParseRole(name string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "viewer":
+		return RoleViewer, nil
+	case "operator":
+		return RoleOperator, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return RoleNone, fmt.Errorf("unknown role %q", name)
+	}
+}
+
+// Identity is an authenticated caller.
+type Identity struct {
+	Name   string // User, token, or proxy-provided name.
+	Method string // Authentication method: token, basic, proxy, or oidc.
+	Role   Role   // Granted role.
+}
+
+// Challenge is the scheme advertised in WWW-Authenticate on 401 responses.
+type Challenge string
+
+const (
+	// ChallengeBasic asks browsers for basic auth credentials.
+	ChallengeBasic Challenge = `Basic realm="heartbeats", charset="UTF-8"`
+	// ChallengeBearer advertises bearer tokens.
+	ChallengeBearer Challenge = `Bearer realm="heartbeats"`
+)
+
+// user is a basic auth user.
+type user struct {
+	hash []byte
+	role Role
+}
+
+// proxy is the compiled trusted proxy configuration.
+type proxy struct {
+	userHeader  string
+	roleHeader  string
+	defaultRole Role
+	trusted     []netip.Prefix
+}
+
+// methods holds the compiled authentication methods.
+type methods struct {
+	tokens map[[sha256.Size]byte]Identity
+	users  map[string]user
+	proxy  *proxy
+	oidc   *OIDC
+	// verified caches successful basic auth checks by the SHA-256 of user
+	// and password, so bcrypt runs once per credential. It is replaced
+	// together with users on Update.
+	verified *sync.Map
+}
+
+// Authenticator checks requests against the configured methods.
+// A nil or unconfigured Authenticator is disabled and allows everything.
+type Authenticator struct {
+	mu      sync.RWMutex
+	m       methods
+	oidcCfg *config.OIDCConfig // Config of m.oidc, to keep sessions across unchanged reloads.
+}
+
+// New builds an Authenticator from config.
+func New(cfg config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{}
+	if err := a.Update(cfg); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Update replaces the configured methods, e.g. after a reload. OIDC
+// sessions are kept when the OIDC settings did not change.
+func (a *Authenticator) Update(cfg config.AuthConfig) error {
+	commit, err := a.Prepare(cfg)
+	if err != nil {
+		return err
+	}
+	commit()
+	return nil
+}
+
+// Prepare builds the methods of cfg without applying them. The returned
+// commit replaces the configured methods and cannot fail, so reloads can
+// reject an invalid auth config before anything is switched.
+func (a *Authenticator) Prepare(cfg config.AuthConfig) (commit func(), err error) {
+	m, err := compile(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OIDC != nil {
+		a.mu.RLock()
+		keep := a.m.oidc != nil && reflect.DeepEqual(a.oidcCfg, cfg.OIDC)
+		m.oidc = a.m.oidc
+		a.mu.RUnlock()
+		if !keep {
+			if m.oidc, err = NewOIDC(*cfg.OIDC, nil); err != nil {
+				return nil, fmt.Errorf("oidc: %w", err)
+			}
+		}
+	}
+	return func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.m = m
+		a.oidcCfg = cfg.OIDC
+	}, nil
+}
+
+// Enabled reports whether any authentication method is configured.
+func (a *Authenticator) Enabled() bool {
+	if a == nil {
+		return false
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.m.tokens) > 0 || len(a.m.users) > 0 || a.m.proxy != nil || a.m.oidc != nil
+}
+
+// OIDC returns the configured OIDC flow, or nil.
+func (a *Authenticator) OIDC() *OIDC {
+	if a == nil {
+		return nil
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.m.oidc
+}
+
+// Login starts the OIDC login flow.
+func (a *Authenticator) Login() http.HandlerFunc {
+	return a.oidcHandler((*OIDC).Login)
+}
+
+// Callback completes the OIDC login flow.
+func (a *Authenticator) Callback() http.HandlerFunc {
+	return a.oidcHandler((*OIDC).Callback)
+}
+
+// Logout ends the OIDC session.
+func (a *Authenticator) Logout() http.HandlerFunc {
+	return a.oidcHandler((*OIDC).Logout)
+}
+
+// oidcHandler dispatches to the current OIDC flow, which may change on reload.
+func (a *Authenticator) oidcHandler(fn func(*OIDC, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		oidc := a.OIDC()
+		if oidc == nil {
+			http.NotFound(w, r)
+			return
+		}
+		fn(oidc, w, r)
+	}
+}
+
+// Challenge returns the scheme to advertise to unauthenticated callers.
+func (a *Authenticator) Challenge() Challenge {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.m.users) > 0 {
+		return ChallengeBasic
+	}
+	return ChallengeBearer
+}
+
+// Authenticate resolves the caller of r. Credentials are tried in order:
+// bearer token, basic auth, OIDC session cookie, trusted proxy headers.
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, bool) {
+	if a == nil {
+		return Identity{}, false
+	}
+	a.mu.RLock()
+	m := a.m
+	a.mu.RUnlock()
+
+	if header := r.Header.Get("Authorization"); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			id, found := m.tokens[sha256.Sum256([]byte(strings.TrimSpace(token)))]
+			return id, found
+		}
+		if username, password, ok := r.BasicAuth(); ok {
+			return m.basic(username, password)
+		}
+	}
+	if m.oidc != nil {
+		if id, ok := m.oidc.Session(r); ok {
+			return id, true
+		}
+	}
+	if m.proxy != nil {
+		return m.proxy.authenticate(r)
+	}
+	return Identity{}, false
+}
+
+// basic checks basic auth credentials, consulting the verification cache
+// before running bcrypt.
+func (m methods) basic(username, password string) (Identity, bool) {
+	u, found := m.users[username]
+	if !found {
+		return Identity{}, false
+	}
+	key := sha256.Sum256([]byte(username + "\x00" + password))
+	if _, ok := m.verified.Load(key); !ok {
+		if bcrypt.CompareHashAndPassword(u.hash, []byte(password)) != nil {
+			return Identity{}, false
+		}
+		m.verified.Store(key, struct{}{})
+	}
+	return Identity{Name: username, Method: "basic", Role: u.role}, true
+}
+
+// authenticate trusts identity headers when the peer is a known proxy.
+func (p *proxy) authenticate(r *http.Request) (Identity, bool) {
+	name := strings.TrimSpace(r.Header.Get(p.userHeader))
+	if name == "" || !p.trustedPeer(r.RemoteAddr) {
+		return Identity{}, false
+	}
+	role := p.defaultRole
+	if p.roleHeader != "" {
+		if value := r.Header.Get(p.roleHeader); value != "" {
+			parsed, err := ParseRole(value)
+			if err != nil {
+				return Identity{}, false
+			}
+			role = parsed
+		}
+	}
+	return Identity{Name: name, Method: "proxy", Role: role}, true
+}
+
+// trustedPeer reports whether remoteAddr is inside a trusted prefix.
+func (p *proxy) trustedPeer(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// compile converts config into lookup structures.
+func compile(cfg config.AuthConfig) (methods, error) {
+	m := methods{
+		tokens:   make(map[[sha256.Size]byte]Identity, len(cfg.Tokens)),
+		users:    make(map[string]user, len(cfg.Users)),
+		verified: &sync.Map{},
+	}
+	for idx, token := range cfg.Tokens {
+		role, err := ParseRole(token.Role)
+		if err != nil {
+			return methods{}, fmt.Errorf("tokens[%d]: %w", idx, err)
+		}
+		name := token.Name
+		if name == "" {
+			name = fmt.Sprintf("token-%d", idx)
+		}
+		// Tokens are looked up by digest so the map never compares raw secrets.
+		m.tokens[sha256.Sum256([]byte(token.Token))] = Identity{Name: name, Method: "token", Role: role}
+	}
+	for idx, u := range cfg.Users {
+		role, err := ParseRole(u.Role)
+		if err != nil {
+			return methods{}, fmt.Errorf("users[%d]: %w", idx, err)
+		}
+		m.users[u.Username] = user{hash: []byte(u.PasswordHash), role: role}
+	}
+	if cfg.TrustedProxy != nil {
+		role, err := ParseRole(cfg.TrustedProxy.DefaultRole)
+		if err != nil {
+			return methods{}, fmt.Errorf("trusted_proxy: %w", err)
+		}
+		p := &proxy{
+			userHeader:  cfg.TrustedProxy.UserHeader,
+			roleHeader:  cfg.TrustedProxy.RoleHeader,
+			defaultRole: role,
+		}
+		for idx, cidr := range cfg.TrustedProxy.TrustedCIDRs {
+			prefix, err := netip.ParsePrefix(cidr)
+			if err != nil {
+				return methods{}, fmt.Errorf("trusted_proxy: trusted_cidrs[%d]: %w", idx, err)
+			}
+			p.trusted = append(p.trusted, prefix.Masked())
+		}
+		m.proxy = p
+	}
+	return m, nil
+}
+
+type contextKey struct{}
+
+// WithIdentity returns a context carrying id.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the identity stored by WithIdentity.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+	return id, ok
+}
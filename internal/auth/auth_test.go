@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/containeroo/heartbeats/internal/config"
+)
+
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	authn, err := New(config.AuthConfig{
+		Tokens: []config.TokenAuthConfig{
+			{Name: "ci", Token: "read-token"},
+			{Name: "ops", Token: "admin-token", Role: "admin"},
+		},
+		Users: []config.BasicAuthUserConfig{
+			{Username: "alice", PasswordHash: string(hash), Role: "admin"},
+		},
+		TrustedProxy: &config.TrustedProxyAuthConfig{
+			UserHeader:   "X-Forwarded-User",
+			RoleHeader:   "X-Forwarded-Role",
+			TrustedCIDRs: []string{"10.0.0.0/8"},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, authn.Enabled())
+	assert.Equal(t, ChallengeBasic, authn.Challenge())
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		basic   []string
+		ok      bool
+		want    Identity
+	}{
+		{name: "viewer token", headers: map[string]string{"Authorization": "Bearer read-token"}, ok: true, want: Identity{Name: "ci", Method: "token", Role: RoleViewer}},
+		{name: "admin token", headers: map[string]string{"Authorization": "Bearer admin-token"}, ok: true, want: Identity{Name: "ops", Method: "token", Role: RoleAdmin}},
+		{name: "unknown token", headers: map[string]string{"Authorization": "Bearer nope"}},
+		{name: "basic auth", basic: []string{"alice", "s3cret"}, ok: true, want: Identity{Name: "alice", Method: "basic", Role: RoleAdmin}},
+		{name: "basic auth wrong password", basic: []string{"alice", "wrong"}},
+		{name: "basic auth unknown user", basic: []string{"bob", "s3cret"}},
+		{name: "trusted proxy", remote: "10.1.2.3:4567", headers: map[string]string{"X-Forwarded-User": "carol"}, ok: true, want: Identity{Name: "carol", Method: "proxy", Role: RoleViewer}},
+		{name: "trusted proxy with role", remote: "10.1.2.3:4567", headers: map[string]string{"X-Forwarded-User": "carol", "X-Forwarded-Role": "admin"}, ok: true, want: Identity{Name: "carol", Method: "proxy", Role: RoleAdmin}},
+		{name: "trusted proxy unknown role", remote: "10.1.2.3:4567", headers: map[string]string{"X-Forwarded-User": "carol", "X-Forwarded-Role": "root"}},
+		{name: "untrusted peer", remote: "192.0.2.1:4567", headers: map[string]string{"X-Forwarded-User": "carol"}},
+		{name: "no credentials", remote: "192.0.2.1:4567"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest("GET", "/api/status", nil)
+			if tc.remote != "" {
+				req.RemoteAddr = tc.remote
+			}
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			if tc.basic != nil {
+				req.SetBasicAuth(tc.basic[0], tc.basic[1])
+			}
+
+			id, ok := authn.Authenticate(req)
+			require.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.want, id)
+		})
+	}
+}
+
+func TestAuthenticatorDisabled(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		var authn *Authenticator
+		assert.False(t, authn.Enabled())
+	})
+
+	t.Run("empty config", func(t *testing.T) {
+		t.Parallel()
+		authn, err := New(config.AuthConfig{})
+		require.NoError(t, err)
+		assert.False(t, authn.Enabled())
+		assert.Equal(t, ChallengeBearer, authn.Challenge())
+	})
+}
+
+func TestAuthenticatorUpdate(t *testing.T) {
+	t.Parallel()
+
+	authn, err := New(config.AuthConfig{Tokens: []config.TokenAuthConfig{{Token: "old"}}})
+	require.NoError(t, err)
+	require.NoError(t, authn.Update(config.AuthConfig{Tokens: []config.TokenAuthConfig{{Token: "new"}}}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer old")
+	_, ok := authn.Authenticate(req)
+	assert.False(t, ok)
+
+	req.Header.Set("Authorization", "Bearer new")
+	id, ok := authn.Authenticate(req)
+	require.True(t, ok)
+	assert.Equal(t, "token-0", id.Name)
+
+	require.Error(t, authn.Update(config.AuthConfig{Tokens: []config.TokenAuthConfig{{Token: "x", Role: "root"}}}))
+
+	commit, err := authn.Prepare(config.AuthConfig{Tokens: []config.TokenAuthConfig{{Token: "next"}}})
+	require.NoError(t, err)
+	_, ok = authn.Authenticate(req)
+	assert.True(t, ok, "prepared methods are not applied yet")
+	commit()
+	_, ok = authn.Authenticate(req)
+	assert.False(t, ok)
+}
+
+func TestAuthenticateBasicCache(t *testing.T) {
+	t.Parallel()
+
+	oldHash, err := bcrypt.GenerateFromPassword([]byte("old"), bcrypt.MinCost)
+	require.NoError(t, err)
+	newHash, err := bcrypt.GenerateFromPassword([]byte("new"), bcrypt.MinCost)
+	require.NoError(t, err)
+	users := func(hash []byte) config.AuthConfig {
+		return config.AuthConfig{Users: []config.BasicAuthUserConfig{{Username: "alice", PasswordHash: string(hash)}}}
+	}
+	authn, err := New(users(oldHash))
+	require.NoError(t, err)
+
+	login := func(password string) bool {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.SetBasicAuth("alice", password)
+		_, ok := authn.Authenticate(req)
+		return ok
+	}
+	cached := func() int {
+		n := 0
+		authn.m.verified.Range(func(any, any) bool { n++; return true })
+		return n
+	}
+
+	assert.False(t, login("wrong"))
+	assert.Equal(t, 0, cached(), "failures are not cached")
+	assert.True(t, login("old"))
+	assert.True(t, login("old"))
+	assert.Equal(t, 1, cached())
+
+	require.NoError(t, authn.Update(users(newHash)))
+	assert.Equal(t, 0, cached(), "update resets the cache")
+	assert.False(t, login("old"))
+	assert.True(t, login("new"))
+}
@@ -0,0 +1,508 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/utils"
+)
+
+const (
+	// SessionCookie is the name of the OIDC session cookie.
+	SessionCookie = "heartbeats_session"
+
+	defaultSessionTTL = 8 * time.Hour
+	loginTimeout      = 10 * time.Minute
+	maxPendingLogins  = 1000 // Unauthenticated callers start logins, so their state is capped.
+	clockSkew         = time.Minute
+)
+
+// oidcProvider is the subset of the discovery document used by the flow.
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// pendingLogin tracks an authorization request until its callback arrives.
+type pendingLogin struct {
+	verifier string
+	nonce    string
+	returnTo string
+	expires  time.Time
+}
+
+// session is an authenticated browser session.
+type session struct {
+	identity Identity
+	expires  time.Time
+}
+
+// OIDC implements the authorization code flow with PKCE and keeps
+// browser sessions in memory.
+type OIDC struct {
+	cfg        config.OIDCConfig
+	client     *http.Client
+	basePath   string // Path prefix of the /auth routes, ending in "/".
+	secure     bool   // Whether cookies are marked Secure.
+	sessionTTL time.Duration
+
+	mu       sync.Mutex
+	provider *oidcProvider
+	keys     map[string]*rsa.PublicKey
+	pending  map[string]pendingLogin
+	sessions map[string]session
+}
+
+// NewOIDC builds an OIDC flow. Discovery happens lazily on the first login
+// so an unreachable identity provider does not block startup.
+func NewOIDC(cfg config.OIDCConfig, client *http.Client) (*OIDC, error) {
+	redirect, err := url.Parse(cfg.RedirectURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse redirect_url: %w", err)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDC{
+		cfg:        cfg,
+		client:     client,
+		basePath:   strings.TrimSuffix(redirect.Path, "auth/callback"),
+		secure:     redirect.Scheme == "https",
+		sessionTTL: utils.DefaultIfZero(cfg.SessionTTL, defaultSessionTTL),
+		keys:       make(map[string]*rsa.PublicKey),
+		pending:    make(map[string]pendingLogin),
+		sessions:   make(map[string]session),
+	}, nil
+}
+
+// LoginURL returns the login path that returns to returnTo afterwards.
+func (o *OIDC) LoginURL(returnTo string) string {
+	return o.basePath + "auth/login?redirect=" + url.QueryEscape(returnTo)
+}
+
+// Session resolves the identity of the session cookie on r.
+func (o *OIDC) Session(r *http.Request) (Identity, bool) {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil || cookie.Value == "" {
+		return Identity{}, false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	sess, ok := o.sessions[cookie.Value]
+	if !ok {
+		return Identity{}, false
+	}
+	if time.Now().After(sess.expires) {
+		delete(o.sessions, cookie.Value)
+		return Identity{}, false
+	}
+	return sess.identity, true
+}
+
+// Login redirects the browser to the identity provider.
+func (o *OIDC) Login(w http.ResponseWriter, r *http.Request) {
+	provider, err := o.discover(r.Context())
+	if err != nil {
+		http.Error(w, "identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	state, verifier, nonce := randomString(), randomString(), randomString()
+	now := time.Now()
+	o.mu.Lock()
+	o.addPendingLocked(state, pendingLogin{
+		verifier: verifier,
+		nonce:    nonce,
+		returnTo: o.safeReturnTo(r.URL.Query().Get("redirect")),
+		expires:  now.Add(loginTimeout),
+	}, now)
+	o.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {o.cfg.ClientID},
+		"redirect_uri":          {o.cfg.RedirectURL},
+		"scope":                 {strings.Join(o.scopes(), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	target := provider.AuthorizationEndpoint
+	if strings.Contains(target, "?") {
+		target += "&" + query.Encode()
+	} else {
+		target += "?" + query.Encode()
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// addPendingLocked stores a login after dropping expired ones. At
+// maxPendingLogins the oldest login is evicted, so memory stays bounded no
+// matter how many logins are started. Callers hold o.mu.
+func (o *OIDC) addPendingLocked(state string, login pendingLogin, now time.Time) {
+	oldest := ""
+	for key, p := range o.pending {
+		if now.After(p.expires) {
+			delete(o.pending, key)
+			continue
+		}
+		if oldest == "" || p.expires.Before(o.pending[oldest].expires) {
+			oldest = key
+		}
+	}
+	if len(o.pending) >= maxPendingLogins {
+		delete(o.pending, oldest)
+	}
+	o.pending[state] = login
+}
+
+// Callback exchanges the authorization code, verifies the ID token and
+// starts a session.
+func (o *OIDC) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		http.Error(w, "login failed: "+errCode, http.StatusUnauthorized)
+		return
+	}
+
+	o.mu.Lock()
+	pending, ok := o.pending[query.Get("state")]
+	delete(o.pending, query.Get("state"))
+	o.mu.Unlock()
+	if !ok || time.Now().After(pending.expires) {
+		http.Error(w, "login expired, please retry", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := o.exchange(r.Context(), query.Get("code"), pending)
+	if err != nil {
+		http.Error(w, "login failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	identity := o.identity(claims)
+	if identity.Role == RoleNone {
+		http.Error(w, "user "+identity.Name+" has no access", http.StatusForbidden)
+		return
+	}
+
+	id := randomString()
+	expires := time.Now().Add(o.sessionTTL)
+	o.mu.Lock()
+	for key, sess := range o.sessions {
+		if time.Now().After(sess.expires) {
+			delete(o.sessions, key)
+		}
+	}
+	o.sessions[id] = session{identity: identity, expires: expires}
+	o.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    id,
+		Path:     o.basePath,
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   o.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, pending.returnTo, http.StatusFound)
+}
+
+// Logout ends the session and returns to the dashboard.
+func (o *OIDC) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		o.mu.Lock()
+		delete(o.sessions, cookie.Value)
+		o.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Path:     o.basePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   o.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, o.basePath, http.StatusSeeOther)
+}
+
+// safeReturnTo only allows local absolute paths to avoid open redirects.
+func (o *OIDC) safeReturnTo(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.Contains(target, "\\") {
+		return o.basePath
+	}
+	return target
+}
+
+// scopes returns the requested scopes, always including openid.
+func (o *OIDC) scopes() []string {
+	if len(o.cfg.Scopes) == 0 {
+		return []string{"openid", "profile", "email"}
+	}
+	if slices.Contains(o.cfg.Scopes, "openid") {
+		return o.cfg.Scopes
+	}
+	return append([]string{"openid"}, o.cfg.Scopes...)
+}
+
+// discover fetches and caches the provider metadata.
+func (o *OIDC) discover(ctx context.Context) (*oidcProvider, error) {
+	o.mu.Lock()
+	cached := o.provider
+	o.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	var provider oidcProvider
+	wellKnown := strings.TrimSuffix(o.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := o.getJSON(ctx, wellKnown, &provider); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != strings.TrimSuffix(o.cfg.Issuer, "/") {
+		return nil, fmt.Errorf("discovery: issuer %q does not match %q", provider.Issuer, o.cfg.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, errors.New("discovery: incomplete provider metadata")
+	}
+
+	o.mu.Lock()
+	o.provider = &provider
+	o.mu.Unlock()
+	return &provider, nil
+}
+
+// exchange redeems the code and returns the verified ID token claims.
+func (o *OIDC) exchange(ctx context.Context, code string, pending pendingLogin) (map[string]any, error) {
+	provider, err := o.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {o.cfg.RedirectURL},
+		"client_id":     {o.cfg.ClientID},
+		"code_verifier": {pending.verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if o.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(o.cfg.ClientID), url.QueryEscape(o.cfg.ClientSecret))
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close() // nolint:errcheck
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil || tokens.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	claims, err := o.verify(ctx, provider, tokens.IDToken)
+	if err != nil {
+		return nil, err
+	}
+	if nonce, _ := claims["nonce"].(string); nonce != pending.nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+	return claims, nil
+}
+
+// verify checks the RS256 signature and standard claims of an ID token.
+func (o *OIDC) verify(ctx context.Context, provider *oidcProvider, token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed id_token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("id_token header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported id_token alg %q", header.Alg)
+	}
+	key, err := o.key(ctx, provider, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed id_token signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, errors.New("invalid id_token signature")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("id_token claims: %w", err)
+	}
+	if iss, _ := claims["iss"].(string); iss != provider.Issuer {
+		return nil, fmt.Errorf("id_token issuer %q does not match", iss)
+	}
+	if !slices.Contains(stringList(claims["aud"]), o.cfg.ClientID) {
+		return nil, errors.New("id_token audience does not include client_id")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok || time.Now().Add(-clockSkew).After(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("id_token expired")
+	}
+	return claims, nil
+}
+
+// key returns the signing key for kid, refreshing the JWKS on a miss.
+func (o *OIDC) key(ctx context.Context, provider *oidcProvider, kid string) (*rsa.PublicKey, error) {
+	o.mu.Lock()
+	key, ok := o.keys[kid]
+	o.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := o.getJSON(ctx, provider.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	o.mu.Lock()
+	o.keys = keys
+	o.mu.Unlock()
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown id_token key %q", kid)
+}
+
+// identity maps claims to an identity using the configured group roles.
+func (o *OIDC) identity(claims map[string]any) Identity {
+	name, _ := claims[utils.DefaultIfZero(o.cfg.UsernameClaim, "preferred_username")].(string)
+	if name == "" {
+		name, _ = claims["sub"].(string)
+	}
+
+	role := RoleNone
+	if o.cfg.DefaultRole != "" {
+		role, _ = ParseRole(o.cfg.DefaultRole)
+	}
+	groups := stringList(claims[utils.DefaultIfZero(o.cfg.GroupsClaim, "groups")])
+	for roleName, mapped := range o.cfg.Roles {
+		candidate, err := ParseRole(roleName)
+		if err != nil || candidate <= role {
+			continue
+		}
+		for _, group := range mapped {
+			if slices.Contains(groups, group) {
+				role = candidate
+				break
+			}
+		}
+	}
+	return Identity{Name: name, Method: "oidc", Role: role}
+}
+
+// getJSON fetches url and decodes a JSON response.
+func (o *OIDC) getJSON(ctx context.Context, target string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// decodeSegment decodes a base64url JWT segment into v.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// stringList normalizes a string or string array claim.
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// randomString returns 32 random bytes encoded as base64url.
+func randomString() string {
+	buf := make([]byte, 32)
+	_, _ = rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
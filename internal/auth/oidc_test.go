@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containeroo/heartbeats/internal/config"
+)
+
+func TestOIDCIdentity(t *testing.T) {
+	t.Parallel()
+
+	oidc, err := NewOIDC(config.OIDCConfig{
+		Issuer:      "https://idp.example.com",
+		ClientID:    "heartbeats",
+		RedirectURL: "https://example.com/heartbeats/auth/callback",
+		GroupsClaim: "roles",
+		Roles: map[string][]string{
+			"admin":    {"hb-admins"},
+			"operator": {"hb-ops"},
+			"viewer":   {"staff"},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		claims map[string]any
+		want   Identity
+	}{
+		{"highest role wins", map[string]any{"preferred_username": "alice", "roles": []any{"staff", "hb-ops"}}, Identity{Name: "alice", Method: "oidc", Role: RoleOperator}},
+		{"single string claim", map[string]any{"preferred_username": "bob", "roles": "hb-admins"}, Identity{Name: "bob", Method: "oidc", Role: RoleAdmin}},
+		{"falls back to sub", map[string]any{"sub": "u-1", "roles": []any{"staff"}}, Identity{Name: "u-1", Method: "oidc", Role: RoleViewer}},
+		{"no mapped group", map[string]any{"sub": "u-2", "roles": []any{"other"}}, Identity{Name: "u-2", Method: "oidc", Role: RoleNone}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, oidc.identity(tc.claims))
+		})
+	}
+
+	t.Run("login url keeps the route prefix", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, "/heartbeats/auth/login?redirect=%2Fheartbeats%2F", oidc.LoginURL("/heartbeats/"))
+	})
+
+	t.Run("return targets must be local", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, "/heartbeats/history", oidc.safeReturnTo("/heartbeats/history"))
+		assert.Equal(t, "/heartbeats/", oidc.safeReturnTo("//evil.example.com"))
+		assert.Equal(t, "/heartbeats/", oidc.safeReturnTo("https://evil.example.com"))
+	})
+}
+
+func TestOIDCPendingLogins(t *testing.T) {
+	t.Parallel()
+
+	oidc, err := NewOIDC(config.OIDCConfig{
+		Issuer:      "https://idp.example.com",
+		ClientID:    "heartbeats",
+		RedirectURL: "https://example.com/auth/callback",
+	}, nil)
+	require.NoError(t, err)
+	oidc.provider = &oidcProvider{AuthorizationEndpoint: "https://idp.example.com/authorize"}
+
+	login := func() {
+		rec := httptest.NewRecorder()
+		oidc.Login(rec, httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+		require.Equal(t, http.StatusFound, rec.Code)
+	}
+	login()
+	var first string
+	for state := range oidc.pending {
+		first = state
+	}
+	for range maxPendingLogins + 10 {
+		login()
+	}
+
+	assert.Len(t, oidc.pending, maxPendingLogins)
+	assert.NotContains(t, oidc.pending, first, "the oldest login is evicted")
+}
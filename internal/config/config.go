@@ -11,6 +11,72 @@ type Config struct {
 	Receivers  map[string]ReceiverConfig  `yaml:"receivers"`  // Receiver definitions.
 	Heartbeats map[string]HeartbeatConfig `yaml:"heartbeats"` // Heartbeat definitions.
 	History    HistoryConfig              `yaml:"history"`    // History configuration.
+	Digests    map[string]DigestConfig    `yaml:"digests"`    // Scheduled digest reports.
+	Auth       AuthConfig                 `yaml:"auth"`       // Dashboard and management API authentication.
+
+	Defaults           *HeartbeatConfig           `yaml:"defaults,omitempty"`            // Settings inherited by every heartbeat and template.
+	HeartbeatTemplates map[string]HeartbeatConfig `yaml:"heartbeat_templates,omitempty"` // Named profiles for extends and auto-provisioning.
+	AutoProvision      *AutoProvisionConfig       `yaml:"auto_provision,omitempty"`      // Create heartbeats for unknown ids on their first ping.
+}
+
+// DefaultAutoProvisionMax caps auto-provisioned heartbeats when no limit is configured.
+const DefaultAutoProvisionMax = 100
+
+// AutoProvisionConfig creates heartbeats for unknown ids that match a rule.
+type AutoProvisionConfig struct {
+	Rules         []AutoProvisionRule `yaml:"rules"`                    // Checked in order; the first match wins.
+	MaxHeartbeats int                 `yaml:"max_heartbeats,omitempty"` // Cap on auto-provisioned heartbeats (default 100).
+}
+
+// AutoProvisionRule maps heartbeat ids to a template.
+type AutoProvisionRule struct {
+	Pattern  string `yaml:"pattern"`  // Glob matched against the id, e.g. "cron-*".
+	Template string `yaml:"template"` // Name of the heartbeat_templates entry.
+}
+
+// AuthConfig configures authentication for the dashboard and management API.
+// Auth is disabled when no method is configured.
+type AuthConfig struct {
+	Tokens       []TokenAuthConfig       `yaml:"tokens,omitempty"`        // Static bearer tokens.
+	Users        []BasicAuthUserConfig   `yaml:"users,omitempty"`         // Basic auth users with bcrypt hashes.
+	TrustedProxy *TrustedProxyAuthConfig `yaml:"trusted_proxy,omitempty"` // Identity headers set by a reverse proxy.
+	OIDC         *OIDCConfig             `yaml:"oidc,omitempty"`          // OpenID Connect single sign-on.
+}
+
+// OIDCConfig configures the OpenID Connect authorization code flow with PKCE.
+type OIDCConfig struct {
+	Issuer        string              `yaml:"issuer"`                   // Issuer URL used for discovery.
+	ClientID      string              `yaml:"client_id"`                // OAuth2 client id.
+	ClientSecret  string              `yaml:"client_secret,omitempty"`  // Client secret; empty for public clients.
+	RedirectURL   string              `yaml:"redirect_url"`             // Absolute URL of .../auth/callback.
+	Scopes        []string            `yaml:"scopes,omitempty"`         // Requested scopes (default openid, profile, email).
+	UsernameClaim string              `yaml:"username_claim,omitempty"` // Claim used as user name (default preferred_username, then sub).
+	GroupsClaim   string              `yaml:"groups_claim,omitempty"`   // Claim holding group names (default groups).
+	Roles         map[string][]string `yaml:"roles,omitempty"`          // Role name to groups granting it.
+	DefaultRole   string              `yaml:"default_role,omitempty"`   // Role for users in no mapped group; empty denies access.
+	SessionTTL    time.Duration       `yaml:"session_ttl,omitempty"`    // Session lifetime (default 8h).
+}
+
+// TokenAuthConfig defines a static bearer token.
+type TokenAuthConfig struct {
+	Name  string `yaml:"name"`           // Identity name for logs.
+	Token string `yaml:"token"`          // Bearer token value.
+	Role  string `yaml:"role,omitempty"` // viewer (default), operator, or admin.
+}
+
+// BasicAuthUserConfig defines a basic auth user.
+type BasicAuthUserConfig struct {
+	Username     string `yaml:"username"`       // Login name.
+	PasswordHash string `yaml:"password_hash"`  // bcrypt hash of the password.
+	Role         string `yaml:"role,omitempty"` // viewer (default), operator, or admin.
+}
+
+// TrustedProxyAuthConfig trusts identity headers from known proxy addresses.
+type TrustedProxyAuthConfig struct {
+	UserHeader   string   `yaml:"user_header"`            // Header carrying the authenticated user.
+	RoleHeader   string   `yaml:"role_header,omitempty"`  // Optional header carrying the role.
+	DefaultRole  string   `yaml:"default_role,omitempty"` // Role when no role header is sent (default viewer).
+	TrustedCIDRs []string `yaml:"trusted_cidrs"`          // Proxy addresses allowed to set the headers.
 }
 
 // ReceiverConfig describes where notifications are delivered.
@@ -32,6 +98,7 @@ type WebhookConfig struct {
 	SubjectTmpl        string              `yaml:"subject_override_tmpl,omitempty"` // Subject template override.
 	LogResponse        webhook.LogResponse `yaml:"log_response,omitempty"`          // Successful response logging mode.
 	ResponseBodyLimit  int                 `yaml:"response_body_limit,omitempty"`   // Maximum response body bytes to read.
+	SigningSecret      string              `yaml:"signing_secret,omitempty"`        // Secret for the X-Heartbeat-Signature HMAC-SHA256 of timestamp and body.
 }
 
 // EmailConfig configures SMTP delivery.
@@ -46,31 +113,96 @@ type EmailConfig struct {
 	StartTLS           bool              `yaml:"starttls"`                        // Enable STARTTLS.
 	SSL                bool              `yaml:"ssl"`                             // Use implicit TLS.
 	InsecureSkipVerify bool              `yaml:"insecure_skip_verify"`            // Skip TLS verification.
-	Template           string            `yaml:"template,omitempty"`              // Email body template path.
+	Template           string            `yaml:"template,omitempty"`              // Email HTML body template path.
+	TextTemplate       string            `yaml:"text_template,omitempty"`         // Email plain-text body template path.
+	StatusImages       bool              `yaml:"status_images,omitempty"`         // Embed a status image referenced as .StatusImage.
 	SubjectTmpl        string            `yaml:"subject_override_tmpl,omitempty"` // Email subject template override.
 }
 
+// Retry backoff strategies.
+const (
+	BackoffFixed       = "fixed"       // Wait delay before every retry.
+	BackoffExponential = "exponential" // Double the delay per retry, up to max_delay.
+)
+
 // RetryConfig defines retry behavior for a receiver.
 type RetryConfig struct {
-	Count int           `yaml:"count"` // Number of retry attempts.
-	Delay time.Duration `yaml:"delay"` // Delay between retries.
+	Count    *int          `yaml:"count,omitempty"`     // Number of retry attempts (default 5); 0 disables retries.
+	Delay    time.Duration `yaml:"delay"`               // Delay before the first retry.
+	Backoff  string        `yaml:"backoff,omitempty"`   // fixed or exponential (default).
+	MaxDelay time.Duration `yaml:"max_delay,omitempty"` // Upper bound for a single delay.
+	Jitter   float64       `yaml:"jitter,omitempty"`    // Random spread of each delay, 0-1 (0.2 = ±20%).
+	RetryOn  []string      `yaml:"retry_on,omitempty"`  // Webhook status codes or classes that are retried, e.g. 5xx or 429.
 }
 
 // HistoryConfig defines in-memory history settings.
 type HistoryConfig struct {
-	Size   int `yaml:"size"`   // Number of events to keep.
-	Buffer int `yaml:"buffer"` // Async buffer size for history events.
+	Size            int `yaml:"size"`                        // Number of events to keep.
+	Buffer          int `yaml:"buffer"`                      // Async buffer size for history events.
+	Payloads        int `yaml:"payloads,omitempty"`          // Payloads kept per heartbeat.
+	PayloadMaxBytes int `yaml:"payload_max_bytes,omitempty"` // Stored bytes per payload.
 }
 
 // HeartbeatConfig defines a monitored heartbeat and its receivers.
 type HeartbeatConfig struct {
-	Title           string        `yaml:"title,omitempty"`             // Human-friendly title.
-	Interval        time.Duration `yaml:"interval"`                    // Expected interval between heartbeats.
-	LateAfter       time.Duration `yaml:"late_after"`                  // Late window duration.
-	AlertOnRecovery *bool         `yaml:"alert_on_recovery,omitempty"` // Enable recovery alerts.
-	AlertOnLate     *bool         `yaml:"alert_on_late,omitempty"`     // Enable late alerts.
-	SubjectTmpl     string        `yaml:"subject_tmpl,omitempty"`      // Default subject template.
-	WebhookTemplate string        `yaml:"webhook_template,omitempty"`  // Default webhook template path.
-	EmailTemplate   string        `yaml:"email_template,omitempty"`    // Default email template path.
-	Receivers       []string      `yaml:"receivers"`                   // Receiver names for this heartbeat.
+	Extends           string          `yaml:"extends,omitempty"`             // Name of the heartbeat_templates entry to inherit from.
+	Title             string          `yaml:"title,omitempty"`               // Human-friendly title.
+	Interval          time.Duration   `yaml:"interval"`                      // Expected interval between heartbeats.
+	LateAfter         time.Duration   `yaml:"late_after"`                    // Late window duration.
+	AlertOnRecovery   *bool           `yaml:"alert_on_recovery,omitempty"`   // Enable recovery alerts.
+	AlertOnLate       *bool           `yaml:"alert_on_late,omitempty"`       // Enable late alerts.
+	SubjectTmpl       string          `yaml:"subject_tmpl,omitempty"`        // Default subject template.
+	WebhookTemplate   string          `yaml:"webhook_template,omitempty"`    // Default webhook template path.
+	EmailTemplate     string          `yaml:"email_template,omitempty"`      // Default email template path.
+	EmailTextTemplate string          `yaml:"email_text_template,omitempty"` // Default plain-text email template path.
+	Receivers         []string        `yaml:"receivers"`                     // Receiver names for this heartbeat.
+	Payload           *PayloadRules   `yaml:"payload,omitempty"`             // Optional payload validation rules.
+	Metrics           []PayloadMetric `yaml:"metrics,omitempty"`             // Numeric payload fields exported as metrics.
+	Ping              *PingConfig     `yaml:"ping,omitempty"`                // Optional ping authentication.
+}
+
+// PingConfig protects a heartbeat against spoofed pings.
+type PingConfig struct {
+	Token      string `yaml:"token,omitempty"`       // Secret sent in the path, X-Heartbeat-Token header, or token query parameter.
+	HMACSecret string `yaml:"hmac_secret,omitempty"` // Secret for the X-Heartbeat-Signature HMAC-SHA256 of the body.
+
+	ClientCertSubjects []string `yaml:"client_cert_subjects,omitempty"` // Verified client certificate CNs, DNs, or DNS names allowed to ping.
+}
+
+// PayloadMetric extracts a numeric JSON payload field into a gauge.
+type PayloadMetric struct {
+	Name string   `yaml:"name"`          // Metric label value.
+	Path string   `yaml:"path"`          // JSON path of the numeric field.
+	Min  *float64 `yaml:"min,omitempty"` // Fail the heartbeat when the value is below.
+	Max  *float64 `yaml:"max,omitempty"` // Fail the heartbeat when the value is above.
+}
+
+// PayloadRules defines checks a heartbeat payload must pass.
+type PayloadRules struct {
+	MaxSize      int                `yaml:"max_size,omitempty"`      // Maximum payload size in bytes.
+	Match        string             `yaml:"match,omitempty"`         // Regex the raw payload must match.
+	RequiredKeys []string           `yaml:"required_keys,omitempty"` // JSON paths that must exist.
+	Assertions   []PayloadAssertion `yaml:"assertions,omitempty"`    // JSON path assertions.
+}
+
+// PayloadAssertion checks the value at a JSON path.
+type PayloadAssertion struct {
+	Path   string `yaml:"path"`             // JSON path, e.g. "result.items[0].status".
+	Equals any    `yaml:"equals,omitempty"` // Expected value.
+	Match  string `yaml:"match,omitempty"`  // Regex the value must match.
+	Exists *bool  `yaml:"exists,omitempty"` // Whether the path must exist (default true).
+}
+
+// DigestConfig defines a scheduled summary report over all heartbeats.
+type DigestConfig struct {
+	Title             string        `yaml:"title,omitempty"`               // Human-friendly title.
+	Schedule          string        `yaml:"schedule"`                      // Cron expression for delivery.
+	Period            time.Duration `yaml:"period,omitempty"`              // Reporting window (default 24h).
+	Timezone          string        `yaml:"timezone,omitempty"`            // IANA timezone for the schedule (default UTC).
+	Template          string        `yaml:"template,omitempty"`            // Report body template path.
+	SubjectTmpl       string        `yaml:"subject_tmpl,omitempty"`        // Default subject template.
+	WebhookTemplate   string        `yaml:"webhook_template,omitempty"`    // Default webhook template path.
+	EmailTemplate     string        `yaml:"email_template,omitempty"`      // Default email template path.
+	EmailTextTemplate string        `yaml:"email_text_template,omitempty"` // Default plain-text email template path.
+	Receivers         []string      `yaml:"receivers"`                     // Receiver names for this digest.
 }
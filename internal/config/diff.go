@@ -0,0 +1,115 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Diff lists what differs between two configs. Changes are reported by
+// field name only, so secrets never appear in a diff.
+type Diff struct {
+	Heartbeats SectionDiff `json:"heartbeats"`         // Changes to heartbeats.
+	Receivers  SectionDiff `json:"receivers"`          // Changes to receivers.
+	Digests    SectionDiff `json:"digests"`            // Changes to digests.
+	Settings   []string    `json:"settings,omitempty"` // Other changed top-level keys, e.g. auth or history.
+}
+
+// SectionDiff lists the added, removed, and changed entries of a config section.
+type SectionDiff struct {
+	Added   []string            `json:"added,omitempty"`   // New entries.
+	Removed []string            `json:"removed,omitempty"` // Entries that no longer exist.
+	Changed map[string][]string `json:"changed,omitempty"` // Changed entries and their changed fields.
+}
+
+// Empty reports whether the section is unchanged.
+func (d SectionDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Empty reports whether the configs are equivalent.
+func (d Diff) Empty() bool {
+	return d.Heartbeats.Empty() && d.Receivers.Empty() && d.Digests.Empty() && len(d.Settings) == 0
+}
+
+// DiffConfigs compares prev with next. A nil prev reports every entry as added.
+func DiffConfigs(prev, next *Config) Diff {
+	if prev == nil {
+		prev = &Config{}
+	}
+	if next == nil {
+		next = &Config{}
+	}
+	return Diff{
+		Heartbeats: diffSection(prev.Heartbeats, next.Heartbeats),
+		Receivers:  diffSection(prev.Receivers, next.Receivers),
+		Digests:    diffSection(prev.Digests, next.Digests),
+		Settings:   changedFields(*prev, *next, "heartbeats", "receivers", "digests"),
+	}
+}
+
+// diffSection compares two maps of config entries.
+func diffSection[T any](prev, next map[string]T) SectionDiff {
+	var d SectionDiff
+	for _, name := range slices.Sorted(maps.Keys(next)) {
+		old, ok := prev[name]
+		if !ok {
+			d.Added = append(d.Added, name)
+			continue
+		}
+		if fields := changedFields(old, next[name]); len(fields) > 0 {
+			if d.Changed == nil {
+				d.Changed = make(map[string][]string)
+			}
+			d.Changed[name] = fields
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(prev)) {
+		if _, ok := next[name]; !ok {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	return d
+}
+
+// changedFields returns the YAML names of the struct fields that differ,
+// except those in skip.
+func changedFields[T any](prev, next T, skip ...string) []string {
+	a, b := reflect.ValueOf(prev), reflect.ValueOf(next)
+	var fields []string
+	for i := range a.NumField() {
+		name, _, _ := strings.Cut(a.Type().Field(i).Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || slices.Contains(skip, name) {
+			continue
+		}
+		if !equalValue(a.Field(i), b.Field(i)) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// equalValue compares two values, treating nil and empty slices and maps alike.
+func equalValue(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Slice, reflect.Map:
+		if a.Len() == 0 && b.Len() == 0 {
+			return true
+		}
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// Hash returns the SHA-256 of the config in its YAML form, so equal configs
+// hash alike regardless of file layout or key order.
+func Hash(cfg *Config) string {
+	data, err := yamlMarshal(cfg)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
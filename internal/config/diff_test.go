@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	t.Parallel()
+
+	prev := &Config{
+		Receivers: map[string]ReceiverConfig{
+			"ops":  {Webhooks: []WebhookConfig{{URL: "https://example.com"}}},
+			"mail": {Emails: []EmailConfig{{Host: "smtp", Pass: "hunter2"}}},
+		},
+		Heartbeats: map[string]HeartbeatConfig{
+			"api":    {Interval: time.Minute, LateAfter: time.Minute, Receivers: []string{"ops"}},
+			"backup": {Interval: time.Hour, LateAfter: time.Minute, Receivers: []string{"ops"}},
+		},
+		History: HistoryConfig{Size: 10},
+	}
+	next := &Config{
+		Receivers: map[string]ReceiverConfig{
+			"ops":  {Webhooks: []WebhookConfig{{URL: "https://example.com"}}},
+			"mail": {Emails: []EmailConfig{{Host: "smtp", Pass: "hunter3"}}},
+		},
+		Heartbeats: map[string]HeartbeatConfig{
+			"api": {Interval: 2 * time.Minute, LateAfter: time.Minute, Receivers: []string{"ops"}, Metrics: []PayloadMetric{}},
+			"db":  {Interval: time.Hour, LateAfter: time.Minute, Receivers: []string{"ops"}},
+		},
+		History: HistoryConfig{Size: 20},
+	}
+
+	diff := DiffConfigs(prev, next)
+	assert.Equal(t, Diff{
+		Heartbeats: SectionDiff{
+			Added:   []string{"db"},
+			Removed: []string{"backup"},
+			Changed: map[string][]string{"api": {"interval"}},
+		},
+		Receivers: SectionDiff{
+			Changed: map[string][]string{"mail": {"emails"}},
+		},
+		Settings: []string{"history"},
+	}, diff)
+	assert.False(t, diff.Empty())
+
+	assert.True(t, DiffConfigs(next, next).Empty())
+	assert.Equal(t, []string{"api", "db"}, DiffConfigs(nil, next).Heartbeats.Added)
+}
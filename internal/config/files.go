@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/containeroo/heartbeats/internal/resolve"
+)
+
+// mergedSections are the top-level keys whose entries may be spread across
+// files, mapped to the noun used in conflict errors.
+var mergedSections = map[string]string{
+	"receivers":           "receiver",
+	"heartbeats":          "heartbeat",
+	"heartbeat_templates": "heartbeat template",
+	"digests":             "digest",
+}
+
+// Files returns the config files selected by path: the file itself, the
+// *.yaml and *.yml files of a directory, or the files matching a glob.
+// Directory entries and matches are sorted; hidden files are skipped, which
+// also skips the ..data directories of Kubernetes ConfigMap mounts.
+func Files(path string) ([]string, error) {
+	var candidates []string
+	switch info, err := os.Stat(path); {
+	case err == nil && info.IsDir():
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config dir: %w", err)
+		}
+		for _, entry := range entries {
+			if ext := filepath.Ext(entry.Name()); ext == ".yaml" || ext == ".yml" {
+				candidates = append(candidates, filepath.Join(path, entry.Name()))
+			}
+		}
+	case err != nil && strings.ContainsAny(path, "*?["):
+		candidates, err = filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("config glob: %w", err)
+		}
+	default:
+		// A single file; a missing one is reported when reading it.
+		return []string{path}, nil
+	}
+
+	files := make([]string, 0, len(candidates))
+	for _, file := range candidates {
+		if strings.HasPrefix(filepath.Base(file), ".") {
+			continue
+		}
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no config files found in %q", path)
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// readConfig reads, expands and merges the config files selected by path.
+// Entries of mergedSections may come from any file but must be unique; every
+// other top-level key may be set in one file only.
+func readConfig(path string, opts LoadOptions) (*Config, error) {
+	files, err := Files(path)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode}
+	sections := make(map[string]*yaml.Node)
+	seen := make(map[string]string) // key or section/entry -> file:line
+	for _, file := range files {
+		root, err := readConfigFile(file, opts)
+		if err != nil {
+			return nil, err
+		}
+		if root == nil {
+			continue
+		}
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			key, value := root.Content[i], root.Content[i+1]
+			at := fmt.Sprintf("%s:%d", file, key.Line)
+
+			noun, ok := mergedSections[key.Value]
+			if !ok {
+				if prev, dup := seen[key.Value]; dup {
+					return nil, fmt.Errorf("%q is set in %s and %s", key.Value, prev, at)
+				}
+				seen[key.Value] = at
+				merged.Content = append(merged.Content, key, value)
+				continue
+			}
+			if value.Kind != yaml.MappingNode {
+				continue // empty section
+			}
+			section := sections[key.Value]
+			if section == nil {
+				section = &yaml.Node{Kind: yaml.MappingNode}
+				sections[key.Value] = section
+				merged.Content = append(merged.Content, key, section)
+			}
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				entry := value.Content[j]
+				id := key.Value + "/" + entry.Value
+				at := fmt.Sprintf("%s:%d", file, entry.Line)
+				if prev, dup := seen[id]; dup {
+					return nil, fmt.Errorf("%s %q is defined in %s and %s", noun, entry.Value, prev, at)
+				}
+				seen[id] = at
+			}
+			section.Content = append(section.Content, value.Content...)
+		}
+	}
+
+	cfg := &Config{}
+	if err := merged.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	return cfg, nil
+}
+
+// readConfigFile returns the top-level mapping of a config file, or nil for an
+// empty file. Errors name the file; YAML errors also carry the line.
+func readConfigFile(file string, opts LoadOptions) (*yaml.Node, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	data, err = resolve.ExpandEnv(data, resolve.Options{Strict: opts.StrictEnv})
+	if err != nil {
+		return nil, fmt.Errorf("resolve env in %s: %w", file, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", file, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("parse config %s: line %d: top level must be a mapping", file, root.Line)
+	}
+	// Decode on its own first so type errors point at this file.
+	if err := root.Decode(&Config{}); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", file, err)
+	}
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+	return root, nil
+}
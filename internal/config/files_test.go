@@ -0,0 +1,145 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	return dir
+}
+
+const receiversFile = `
+receivers:
+  ops:
+    webhooks:
+      - url: https://example.com
+history:
+  size: 10
+`
+
+func TestFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"b.yaml":           "",
+		"a.yml":            "",
+		"notes.txt":        "",
+		".hidden.yaml":     "",
+		"..data/c.yaml":    "",
+		"nested/d.yaml":    "",
+		"team-api.yaml":    "",
+		"team-backup.yaml": "",
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		t.Parallel()
+		files, err := Files(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(dir, "a.yml"),
+			filepath.Join(dir, "b.yaml"),
+			filepath.Join(dir, "team-api.yaml"),
+			filepath.Join(dir, "team-backup.yaml"),
+		}, files)
+	})
+
+	t.Run("glob", func(t *testing.T) {
+		t.Parallel()
+		files, err := Files(filepath.Join(dir, "team-*.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, "team-api.yaml"), filepath.Join(dir, "team-backup.yaml")}, files)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		t.Parallel()
+		_, err := Files(filepath.Join(dir, "prod-*.yaml"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no config files found")
+	})
+
+	t.Run("single file", func(t *testing.T) {
+		t.Parallel()
+		files, err := Files(filepath.Join(dir, "notes.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, "notes.txt")}, files)
+	})
+}
+
+func TestLoadMultipleFiles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("merges sections", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"00-receivers.yaml": receiversFile,
+			"api.yaml": `
+heartbeats:
+  api:
+    interval: 1m
+    late_after: 30s
+    receivers: ["ops"]
+`,
+			"backup.yaml": `
+heartbeats:
+  backup:
+    interval: 24h
+    late_after: 1h
+    receivers: ["ops"]
+`,
+			"empty.yaml": "",
+		})
+
+		cfg, err := Load(dir)
+		require.NoError(t, err)
+		assert.Len(t, cfg.Heartbeats, 2)
+		assert.Contains(t, cfg.Receivers, "ops")
+		assert.Equal(t, 10, cfg.History.Size)
+	})
+
+	t.Run("duplicate entry", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"a.yaml": receiversFile,
+			"b.yaml": "\nreceivers:\n  ops:\n    webhooks:\n      - url: https://example.org\n",
+		})
+
+		_, err := Load(dir)
+		require.EqualError(t, err, `receiver "ops" is defined in `+filepath.Join(dir, "a.yaml")+`:3 and `+filepath.Join(dir, "b.yaml")+`:3`)
+	})
+
+	t.Run("duplicate top-level key", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"a.yaml": receiversFile,
+			"b.yaml": "history:\n  size: 20\n",
+		})
+
+		_, err := Load(filepath.Join(dir, "*.yaml"))
+		require.EqualError(t, err, `"history" is set in `+filepath.Join(dir, "a.yaml")+`:6 and `+filepath.Join(dir, "b.yaml")+`:1`)
+	})
+
+	t.Run("parse error names the file", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"a.yaml": receiversFile,
+			"b.yaml": "heartbeats:\n  api:\n    interval: soon\n",
+		})
+
+		_, err := Load(dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parse config "+filepath.Join(dir, "b.yaml"))
+		assert.Contains(t, err.Error(), "line 3")
+	})
+}
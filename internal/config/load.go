@@ -3,37 +3,50 @@ package config
 import (
 	"errors"
 	"fmt"
-	"os"
+	"maps"
+	"net/netip"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/containeroo/heartbeats/internal/resolve"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/containeroo/heartbeats/internal/cron"
 )
 
-// Load reads and validates a YAML configuration file.
+// Load reads and validates a YAML configuration.
 func Load(path string) (*Config, error) {
 	return LoadWithOptions(path, LoadOptions{})
 }
 
 // LoadOptions controls config loading behavior.
 type LoadOptions struct {
-	StrictEnv bool // Whether unresolved env vars should error.
+	StrictEnv  bool          // Whether unresolved env vars should error.
+	Overlay    OverlaySource // Runtime changes merged over the file before validation; may be nil.
+	NoValidate bool          // Return the resolved config without validating it.
 }
 
-// LoadWithOptions reads and validates a YAML configuration file.
+// LoadWithOptions reads and validates a YAML configuration. path is a file,
+// a directory of *.yaml/*.yml files, or a glob; multiple files are merged.
 func LoadWithOptions(path string, opts LoadOptions) (*Config, error) {
 	if path == "" {
 		return nil, errors.New("config path is required")
 	}
-	data, err := os.ReadFile(path)
+	cfg, err := readConfig(path, opts)
 	if err != nil {
-		return nil, fmt.Errorf("read config: %w", err)
+		return nil, err
 	}
-	data, err = resolve.ExpandEnv(data, resolve.Options{Strict: opts.StrictEnv})
-	if err != nil {
-		return nil, fmt.Errorf("resolve env: %w", err)
+	if opts.Overlay != nil {
+		opts.Overlay.Overlay().Apply(cfg)
 	}
-	cfg := &Config{}
-	if err := yamlUnmarshal(data, cfg); err != nil {
-		return nil, fmt.Errorf("parse config: %w", err)
+	if err := cfg.Resolve(); err != nil {
+		return nil, err
+	}
+	if opts.NoValidate {
+		return cfg, nil
 	}
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -41,15 +54,22 @@ func LoadWithOptions(path string, opts LoadOptions) (*Config, error) {
 	return cfg, nil
 }
 
-// Validate checks for missing or invalid config values.
+// Validate checks for missing or invalid config values. Every invalid
+// section and entry is reported, joined into one error.
 func (c *Config) Validate() error {
-	if err := validateReceivers(c.Receivers); err != nil {
-		return err
+	errs := []error{
+		validateReceivers(c.Receivers),
+		validateHeartbeats(c.Heartbeats, c.Receivers),
+		validateDigests(c.Digests, c.Receivers),
+		validateAuth(c.Auth),
 	}
-	if err := validateHeartbeats(c.Heartbeats, c.Receivers); err != nil {
-		return err
+	if c.AutoProvision != nil {
+		if err := validateAutoProvision(*c.AutoProvision, c.HeartbeatTemplates, c.Receivers); err != nil {
+			errs = append(errs, fmt.Errorf("auto_provision: %w", err))
+		}
 	}
-	return validateHistory(c.History)
+	errs = append(errs, validateHistory(c.History))
+	return errors.Join(errs...)
 }
 
 // validateReceivers validates a map of receiver configurations.
@@ -57,12 +77,11 @@ func validateReceivers(receivers map[string]ReceiverConfig) error {
 	if len(receivers) == 0 {
 		return errors.New("at least one receiver is required")
 	}
-	for name, rcv := range receivers {
-		if err := validateReceiver(name, rcv); err != nil {
-			return err
-		}
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(receivers)) {
+		errs = append(errs, validateReceiver(name, receivers[name]))
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // validateReceiver validates a single receiver configuration.
@@ -80,6 +99,9 @@ func validateReceiver(name string, rcv ReceiverConfig) error {
 			return fmt.Errorf("receiver %q email[%d] host/from/to are required", name, idx)
 		}
 	}
+	if err := validateRetry(rcv.Retry); err != nil {
+		return fmt.Errorf("receiver %q retry: %w", name, err)
+	}
 	return nil
 }
 
@@ -88,12 +110,11 @@ func validateHeartbeats(heartbeats map[string]HeartbeatConfig, receivers map[str
 	if len(heartbeats) == 0 {
 		return errors.New("at least one heartbeat is required")
 	}
-	for id, hb := range heartbeats {
-		if err := validateHeartbeat(id, hb, receivers); err != nil {
-			return err
-		}
+	var errs []error
+	for _, id := range slices.Sorted(maps.Keys(heartbeats)) {
+		errs = append(errs, validateHeartbeat(id, heartbeats[id], receivers))
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // validateHeartbeat validates a single heartbeat configuration.
@@ -112,6 +133,151 @@ func validateHeartbeat(id string, hb HeartbeatConfig, receivers map[string]Recei
 			return fmt.Errorf("heartbeat %q references unknown receiver %q", id, r)
 		}
 	}
+	if hb.Payload != nil {
+		if err := validatePayloadRules(*hb.Payload); err != nil {
+			return fmt.Errorf("heartbeat %q payload: %w", id, err)
+		}
+	}
+	if err := validatePayloadMetrics(hb.Metrics); err != nil {
+		return fmt.Errorf("heartbeat %q metrics: %w", id, err)
+	}
+	if hb.Ping != nil {
+		if err := validatePing(*hb.Ping); err != nil {
+			return fmt.Errorf("heartbeat %q ping: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// validatePing validates ping authentication settings.
+func validatePing(ping PingConfig) error {
+	if ping.Token == "" && ping.HMACSecret == "" && len(ping.ClientCertSubjects) == 0 {
+		return errors.New("token, hmac_secret, or client_cert_subjects is required")
+	}
+	for idx, subject := range ping.ClientCertSubjects {
+		if strings.TrimSpace(subject) == "" {
+			return fmt.Errorf("client_cert_subjects[%d] must not be empty", idx)
+		}
+	}
+	if strings.ContainsAny(ping.Token, "/?#") {
+		return errors.New("token must not contain '/', '?' or '#'")
+	}
+	if ping.Token == "payloads" {
+		// GET /api/heartbeat/{id}/payloads is the payload history endpoint.
+		return errors.New(`token must not be "payloads"`)
+	}
+	return nil
+}
+
+// metricNamePattern restricts payload metric names to Prometheus-safe identifiers.
+var metricNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// validatePayloadMetrics validates payload metric extraction rules.
+func validatePayloadMetrics(metrics []PayloadMetric) error {
+	seen := make(map[string]struct{}, len(metrics))
+	for idx, metric := range metrics {
+		if !metricNamePattern.MatchString(metric.Name) {
+			return fmt.Errorf("[%d] name %q must match %s", idx, metric.Name, metricNamePattern.String())
+		}
+		if _, ok := seen[metric.Name]; ok {
+			return fmt.Errorf("[%d] duplicate name %q", idx, metric.Name)
+		}
+		seen[metric.Name] = struct{}{}
+		if strings.TrimSpace(metric.Path) == "" {
+			return fmt.Errorf("[%d] path is required", idx)
+		}
+		if metric.Min != nil && metric.Max != nil && *metric.Min > *metric.Max {
+			return fmt.Errorf("[%d] min must be <= max", idx)
+		}
+	}
+	return nil
+}
+
+// validatePayloadRules validates payload validation rules.
+func validatePayloadRules(rules PayloadRules) error {
+	if rules.MaxSize < 0 {
+		return errors.New("max_size must be >= 0")
+	}
+	if rules.Match != "" {
+		if _, err := regexp.Compile(rules.Match); err != nil {
+			return fmt.Errorf("match: %w", err)
+		}
+	}
+	for idx, key := range rules.RequiredKeys {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("required_keys[%d] must not be empty", idx)
+		}
+	}
+	for idx, assertion := range rules.Assertions {
+		if strings.TrimSpace(assertion.Path) == "" {
+			return fmt.Errorf("assertions[%d] path is required", idx)
+		}
+		if assertion.Match != "" {
+			if _, err := regexp.Compile(assertion.Match); err != nil {
+				return fmt.Errorf("assertions[%d] match: %w", idx, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateDigests validates a map of digest configurations.
+func validateDigests(digests map[string]DigestConfig, receivers map[string]ReceiverConfig) error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(digests)) {
+		errs = append(errs, validateDigest(name, digests[name], receivers))
+	}
+	return errors.Join(errs...)
+}
+
+// validateDigest validates a single digest configuration.
+func validateDigest(name string, digest DigestConfig, receivers map[string]ReceiverConfig) error {
+	if _, err := cron.Parse(digest.Schedule); err != nil {
+		return fmt.Errorf("digest %q schedule: %w", name, err)
+	}
+	if digest.Period < 0 {
+		return fmt.Errorf("digest %q period must be >= 0", name)
+	}
+	if digest.Timezone != "" {
+		if _, err := time.LoadLocation(digest.Timezone); err != nil {
+			return fmt.Errorf("digest %q timezone: %w", name, err)
+		}
+	}
+	if len(digest.Receivers) == 0 {
+		return fmt.Errorf("digest %q must have at least one receiver", name)
+	}
+	for _, r := range digest.Receivers {
+		if _, ok := receivers[r]; !ok {
+			return fmt.Errorf("digest %q references unknown receiver %q", name, r)
+		}
+	}
+	return nil
+}
+
+// validateAutoProvision validates auto-provisioning rules. Templates used by
+// a rule must be complete heartbeats; other templates may be partial profiles.
+func validateAutoProvision(cfg AutoProvisionConfig, templates map[string]HeartbeatConfig, receivers map[string]ReceiverConfig) error {
+	if len(cfg.Rules) == 0 {
+		return errors.New("at least one rule is required")
+	}
+	if cfg.MaxHeartbeats < 0 {
+		return errors.New("max_heartbeats must be >= 0")
+	}
+	for idx, rule := range cfg.Rules {
+		if rule.Pattern == "" {
+			return fmt.Errorf("rules[%d] pattern is required", idx)
+		}
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return fmt.Errorf("rules[%d] pattern %q: %w", idx, rule.Pattern, err)
+		}
+		tmpl, ok := templates[rule.Template]
+		if !ok {
+			return fmt.Errorf("rules[%d] references unknown template %q", idx, rule.Template)
+		}
+		if err := validateHeartbeat(rule.Template, tmpl, receivers); err != nil {
+			return fmt.Errorf("rules[%d] template: %w", idx, err)
+		}
+	}
 	return nil
 }
 
@@ -123,5 +289,110 @@ func validateHistory(cfg HistoryConfig) error {
 	if cfg.Buffer < 0 {
 		return errors.New("history buffer must be >= 0")
 	}
+	if cfg.Payloads < 0 {
+		return errors.New("history payloads must be >= 0")
+	}
+	if cfg.PayloadMaxBytes < 0 {
+		return errors.New("history payload_max_bytes must be >= 0")
+	}
+	return nil
+}
+
+// validateAuth validates authentication settings.
+func validateAuth(cfg AuthConfig) error {
+	tokens := make(map[string]struct{}, len(cfg.Tokens))
+	for idx, token := range cfg.Tokens {
+		if token.Token == "" {
+			return fmt.Errorf("auth tokens[%d]: token is required", idx)
+		}
+		if _, ok := tokens[token.Token]; ok {
+			return fmt.Errorf("auth tokens[%d]: duplicate token", idx)
+		}
+		tokens[token.Token] = struct{}{}
+		if err := validateRole(token.Role); err != nil {
+			return fmt.Errorf("auth tokens[%d]: %w", idx, err)
+		}
+	}
+	users := make(map[string]struct{}, len(cfg.Users))
+	for idx, user := range cfg.Users {
+		if strings.TrimSpace(user.Username) == "" {
+			return fmt.Errorf("auth users[%d]: username is required", idx)
+		}
+		if _, ok := users[user.Username]; ok {
+			return fmt.Errorf("auth users[%d]: duplicate username %q", idx, user.Username)
+		}
+		users[user.Username] = struct{}{}
+		if _, err := bcrypt.Cost([]byte(user.PasswordHash)); err != nil {
+			return fmt.Errorf("auth users[%d]: password_hash must be a bcrypt hash: %w", idx, err)
+		}
+		if err := validateRole(user.Role); err != nil {
+			return fmt.Errorf("auth users[%d]: %w", idx, err)
+		}
+	}
+	if proxy := cfg.TrustedProxy; proxy != nil {
+		if strings.TrimSpace(proxy.UserHeader) == "" {
+			return errors.New("auth trusted_proxy: user_header is required")
+		}
+		if len(proxy.TrustedCIDRs) == 0 {
+			return errors.New("auth trusted_proxy: at least one trusted_cidrs entry is required")
+		}
+		for idx, cidr := range proxy.TrustedCIDRs {
+			if _, err := netip.ParsePrefix(cidr); err != nil {
+				return fmt.Errorf("auth trusted_proxy: trusted_cidrs[%d]: %w", idx, err)
+			}
+		}
+		if err := validateRole(proxy.DefaultRole); err != nil {
+			return fmt.Errorf("auth trusted_proxy: default_role: %w", err)
+		}
+	}
+	if cfg.OIDC != nil {
+		if err := validateOIDC(*cfg.OIDC); err != nil {
+			return fmt.Errorf("auth oidc: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateOIDC validates OpenID Connect settings.
+func validateOIDC(cfg OIDCConfig) error {
+	issuer, err := url.Parse(cfg.Issuer)
+	if err != nil || issuer.Scheme == "" || issuer.Host == "" {
+		return fmt.Errorf("issuer %q must be an absolute URL", cfg.Issuer)
+	}
+	if cfg.ClientID == "" {
+		return errors.New("client_id is required")
+	}
+	redirect, err := url.Parse(cfg.RedirectURL)
+	if err != nil || redirect.Scheme == "" || redirect.Host == "" {
+		return fmt.Errorf("redirect_url %q must be an absolute URL", cfg.RedirectURL)
+	}
+	if !strings.HasSuffix(redirect.Path, "/auth/callback") {
+		return fmt.Errorf("redirect_url %q must end with /auth/callback", cfg.RedirectURL)
+	}
+	for role := range cfg.Roles {
+		if role == "" {
+			return errors.New("roles: role name is required")
+		}
+		if err := validateRole(role); err != nil {
+			return fmt.Errorf("roles: %w", err)
+		}
+	}
+	if err := validateRole(cfg.DefaultRole); err != nil {
+		return fmt.Errorf("default_role: %w", err)
+	}
+	if cfg.SessionTTL < 0 {
+		return errors.New("session_ttl must be >= 0")
+	}
 	return nil
 }
+
+// validateRole checks that a role name is known. Empty means viewer. Names
+// are compared like auth.ParseRole does: trimmed and case-insensitive.
+func validateRole(role string) error {
+	switch strings.ToLower(strings.TrimSpace(role)) {
+	case "", "viewer", "operator", "admin":
+		return nil
+	default:
+		return fmt.Errorf("unknown role %q (want viewer, operator or admin)", role)
+	}
+}
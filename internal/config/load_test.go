@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -39,6 +40,27 @@ func TestValidate(t *testing.T) {
 
 		require.NoError(t, cfg.Validate())
 	})
+	t.Run("reports every error", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Receivers: map[string]ReceiverConfig{
+				"ops": {},
+			},
+			Heartbeats: map[string]HeartbeatConfig{
+				"b": {Interval: time.Second, Receivers: []string{"ops"}},
+				"a": {LateAfter: time.Second, Receivers: []string{"pager"}},
+			},
+			History: HistoryConfig{Size: 100, Buffer: 1},
+		}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		lines := strings.Split(err.Error(), "\n")
+		require.Len(t, lines, 3)
+		assert.Contains(t, lines[0], `receiver "ops"`)
+		assert.Contains(t, lines[1], `heartbeat "a"`)
+		assert.Contains(t, lines[2], `heartbeat "b"`)
+	})
 	t.Run("error - missing receiver", func(t *testing.T) {
 		t.Parallel()
 		cfg := &Config{
@@ -71,6 +93,265 @@ func TestValidate(t *testing.T) {
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "heartbeat")
 	})
+	t.Run("error - digest schedule invalid", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Receivers: map[string]ReceiverConfig{
+				"ops": {
+					Webhooks: []WebhookConfig{{URL: "https://example.com"}},
+				},
+			},
+			Heartbeats: map[string]HeartbeatConfig{
+				"api": {
+					Interval:  3 * time.Second,
+					LateAfter: 2 * time.Second,
+					Receivers: []string{"ops"},
+				},
+			},
+			Digests: map[string]DigestConfig{
+				"daily": {Schedule: "0 25 * * *", Receivers: []string{"ops"}},
+			},
+		}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `digest "daily" schedule`)
+	})
+	t.Run("error - digest unknown receiver", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Receivers: map[string]ReceiverConfig{
+				"ops": {
+					Webhooks: []WebhookConfig{{URL: "https://example.com"}},
+				},
+			},
+			Heartbeats: map[string]HeartbeatConfig{
+				"api": {
+					Interval:  3 * time.Second,
+					LateAfter: 2 * time.Second,
+					Receivers: []string{"ops"},
+				},
+			},
+			Digests: map[string]DigestConfig{
+				"daily": {Schedule: "@daily", Receivers: []string{"managers"}},
+			},
+		}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown receiver "managers"`)
+	})
+	t.Run("error - auth invalid password hash", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Receivers: map[string]ReceiverConfig{
+				"ops": {
+					Webhooks: []WebhookConfig{{URL: "https://example.com"}},
+				},
+			},
+			Heartbeats: map[string]HeartbeatConfig{
+				"api": {
+					Interval:  3 * time.Second,
+					LateAfter: 2 * time.Second,
+					Receivers: []string{"ops"},
+				},
+			},
+			Auth: AuthConfig{
+				Users: []BasicAuthUserConfig{{Username: "alice", PasswordHash: "plaintext"}},
+			},
+		}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "auth users[0]: password_hash must be a bcrypt hash")
+	})
+	t.Run("error - auth unknown role", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Receivers: map[string]ReceiverConfig{
+				"ops": {
+					Webhooks: []WebhookConfig{{URL: "https://example.com"}},
+				},
+			},
+			Heartbeats: map[string]HeartbeatConfig{
+				"api": {
+					Interval:  3 * time.Second,
+					LateAfter: 2 * time.Second,
+					Receivers: []string{"ops"},
+				},
+			},
+			Auth: AuthConfig{
+				Tokens: []TokenAuthConfig{{Token: "t", Role: "root"}},
+			},
+		}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `auth tokens[0]: unknown role "root"`)
+	})
+	t.Run("auth roles are case-insensitive", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Receivers: map[string]ReceiverConfig{
+				"ops": {
+					Webhooks: []WebhookConfig{{URL: "https://example.com"}},
+				},
+			},
+			Heartbeats: map[string]HeartbeatConfig{
+				"api": {
+					Interval:  3 * time.Second,
+					LateAfter: 2 * time.Second,
+					Receivers: []string{"ops"},
+				},
+			},
+			Auth: AuthConfig{
+				Tokens: []TokenAuthConfig{{Token: "t", Role: "Admin"}, {Token: "u", Role: " OPERATOR "}},
+			},
+		}
+
+		require.NoError(t, cfg.Validate())
+	})
+	t.Run("error - oidc redirect_url without callback path", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Receivers: map[string]ReceiverConfig{
+				"ops": {
+					Webhooks: []WebhookConfig{{URL: "https://example.com"}},
+				},
+			},
+			Heartbeats: map[string]HeartbeatConfig{
+				"api": {
+					Interval:  3 * time.Second,
+					LateAfter: 2 * time.Second,
+					Receivers: []string{"ops"},
+				},
+			},
+			Auth: AuthConfig{
+				OIDC: &OIDCConfig{
+					Issuer:      "https://idp.example.com",
+					ClientID:    "heartbeats",
+					RedirectURL: "https://heartbeats.example.com/callback",
+				},
+			},
+		}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "auth oidc: redirect_url")
+	})
+	t.Run("error - ping without secrets", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Receivers: map[string]ReceiverConfig{
+				"ops": {
+					Webhooks: []WebhookConfig{{URL: "https://example.com"}},
+				},
+			},
+			Heartbeats: map[string]HeartbeatConfig{
+				"api": {
+					Interval:  3 * time.Second,
+					LateAfter: 2 * time.Second,
+					Receivers: []string{"ops"},
+					Ping:      &PingConfig{},
+				},
+			},
+		}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `heartbeat "api" ping: token, hmac_secret, or client_cert_subjects is required`)
+	})
+	t.Run("error - ping with empty client cert subject", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Receivers: map[string]ReceiverConfig{
+				"ops": {
+					Webhooks: []WebhookConfig{{URL: "https://example.com"}},
+				},
+			},
+			Heartbeats: map[string]HeartbeatConfig{
+				"api": {
+					Interval:  3 * time.Second,
+					LateAfter: 2 * time.Second,
+					Receivers: []string{"ops"},
+					Ping:      &PingConfig{ClientCertSubjects: []string{"backup", " "}},
+				},
+			},
+		}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `heartbeat "api" ping: client_cert_subjects[1] must not be empty`)
+	})
+	t.Run("error - auto provision unknown template", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Receivers: map[string]ReceiverConfig{
+				"ops": {
+					Webhooks: []WebhookConfig{{URL: "https://example.com"}},
+				},
+			},
+			Heartbeats: map[string]HeartbeatConfig{
+				"api": {Interval: 3 * time.Second, LateAfter: 2 * time.Second, Receivers: []string{"ops"}},
+			},
+			HeartbeatTemplates: map[string]HeartbeatConfig{
+				"cron": {Interval: time.Hour, LateAfter: time.Minute, Receivers: []string{"ops"}},
+			},
+			AutoProvision: &AutoProvisionConfig{Rules: []AutoProvisionRule{
+				{Pattern: "cron-*", Template: "cron"},
+				{Pattern: "job-*", Template: "job"},
+			}},
+		}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `auto_provision: rules[1] references unknown template "job"`)
+	})
+	t.Run("error - auto provision bad pattern", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Receivers: map[string]ReceiverConfig{
+				"ops": {
+					Webhooks: []WebhookConfig{{URL: "https://example.com"}},
+				},
+			},
+			Heartbeats: map[string]HeartbeatConfig{
+				"api": {Interval: 3 * time.Second, LateAfter: 2 * time.Second, Receivers: []string{"ops"}},
+			},
+			HeartbeatTemplates: map[string]HeartbeatConfig{
+				"cron": {Interval: time.Hour, LateAfter: time.Minute, Receivers: []string{"ops"}},
+			},
+			AutoProvision: &AutoProvisionConfig{Rules: []AutoProvisionRule{{Pattern: "cron-[", Template: "cron"}}},
+		}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `auto_provision: rules[0] pattern "cron-["`)
+	})
+	t.Run("error - payload metric min above max", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Receivers: map[string]ReceiverConfig{
+				"ops": {
+					Webhooks: []WebhookConfig{{URL: "https://example.com"}},
+				},
+			},
+			Heartbeats: map[string]HeartbeatConfig{
+				"api": {
+					Interval:  3 * time.Second,
+					LateAfter: 2 * time.Second,
+					Receivers: []string{"ops"},
+					Metrics: []PayloadMetric{
+						{Name: "bytes", Path: "bytes", Min: utils.ToPtr(10.0), Max: utils.ToPtr(1.0)},
+					},
+				},
+			},
+		}
+
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `heartbeat "api" metrics: [0] min must be <= max`)
+	})
 }
 
 func TestLoad(t *testing.T) {
@@ -0,0 +1,308 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// RedactedSecret replaces secret values in API responses. Sending it back
+// keeps the stored value.
+const RedactedSecret = "<redacted>"
+
+// Overlay holds heartbeats and receivers created, changed, or deleted at
+// runtime through the API. It is merged over the config file on every load.
+type Overlay struct {
+	Heartbeats        map[string]HeartbeatConfig `yaml:"heartbeats,omitempty"`         // Created or replaced heartbeats.
+	DeletedHeartbeats []string                   `yaml:"deleted_heartbeats,omitempty"` // Heartbeats removed from the file config.
+	Receivers         map[string]ReceiverConfig  `yaml:"receivers,omitempty"`          // Created or replaced receivers.
+	DeletedReceivers  []string                   `yaml:"deleted_receivers,omitempty"`  // Receivers removed from the file config.
+	Provisioned       []string                   `yaml:"provisioned,omitempty"`        // Heartbeats created by auto-provisioning.
+}
+
+// OverlaySource supplies the current overlay when loading config.
+type OverlaySource interface {
+	Overlay() Overlay
+}
+
+// Apply merges the overlay into cfg. Deletions are applied first, so a
+// heartbeat recreated after a delete is kept.
+func (o Overlay) Apply(cfg *Config) {
+	cfg.Heartbeats = applyEntries(cfg.Heartbeats, o.Heartbeats, o.DeletedHeartbeats)
+	cfg.Receivers = applyEntries(cfg.Receivers, o.Receivers, o.DeletedReceivers)
+}
+
+// applyEntries removes deleted keys from dst and then sets the entries of put.
+func applyEntries[V any](dst, put map[string]V, deleted []string) map[string]V {
+	for _, key := range deleted {
+		delete(dst, key)
+	}
+	if len(put) > 0 && dst == nil {
+		dst = make(map[string]V, len(put))
+	}
+	for key, value := range put {
+		dst[key] = value
+	}
+	return dst
+}
+
+// PutHeartbeat records hb as the definition of id.
+func (o *Overlay) PutHeartbeat(id string, hb HeartbeatConfig) {
+	if o.Heartbeats == nil {
+		o.Heartbeats = make(map[string]HeartbeatConfig)
+	}
+	o.Heartbeats[id] = hb
+	o.DeletedHeartbeats = slices.DeleteFunc(o.DeletedHeartbeats, func(deleted string) bool { return deleted == id })
+}
+
+// DeleteHeartbeat records the removal of id.
+func (o *Overlay) DeleteHeartbeat(id string) {
+	delete(o.Heartbeats, id)
+	o.Provisioned = slices.DeleteFunc(o.Provisioned, func(provisioned string) bool { return provisioned == id })
+	if !slices.Contains(o.DeletedHeartbeats, id) {
+		o.DeletedHeartbeats = append(o.DeletedHeartbeats, id)
+	}
+}
+
+// PutReceiver records rcv as the definition of name.
+func (o *Overlay) PutReceiver(name string, rcv ReceiverConfig) {
+	if o.Receivers == nil {
+		o.Receivers = make(map[string]ReceiverConfig)
+	}
+	o.Receivers[name] = rcv
+	o.DeletedReceivers = slices.DeleteFunc(o.DeletedReceivers, func(deleted string) bool { return deleted == name })
+}
+
+// DeleteReceiver records the removal of name.
+func (o *Overlay) DeleteReceiver(name string) {
+	delete(o.Receivers, name)
+	if !slices.Contains(o.DeletedReceivers, name) {
+		o.DeletedReceivers = append(o.DeletedReceivers, name)
+	}
+}
+
+// ParseHeartbeat decodes a single heartbeat definition from YAML or JSON.
+// Unknown fields are rejected to surface typos.
+func ParseHeartbeat(data []byte) (HeartbeatConfig, error) {
+	return parseDefinition[HeartbeatConfig]("heartbeat", data)
+}
+
+// ParseReceiver decodes a single receiver definition from YAML or JSON.
+// Unknown fields are rejected to surface typos.
+func ParseReceiver(data []byte) (ReceiverConfig, error) {
+	return parseDefinition[ReceiverConfig]("receiver", data)
+}
+
+// parseDefinition strictly decodes a single config entry.
+func parseDefinition[T any](kind string, data []byte) (T, error) {
+	var v T
+	if err := yamlUnmarshalStrict(data, &v); err != nil {
+		var zero T
+		if errors.Is(err, io.EOF) {
+			return zero, fmt.Errorf("empty %s definition", kind)
+		}
+		return zero, fmt.Errorf("parse %s: %w", kind, err)
+	}
+	return v, nil
+}
+
+// Redacted returns a copy of the receiver with secret values replaced by
+// RedactedSecret: the SMTP password, webhook signing secrets, header values,
+// vars values, and the userinfo password, path, and query values of webhook
+// URLs, which often carry tokens (e.g. Slack incoming webhooks).
+func (r ReceiverConfig) Redacted() ReceiverConfig {
+	out := r
+	out.Webhooks = slices.Clone(r.Webhooks)
+	for idx := range out.Webhooks {
+		out.Webhooks[idx].URL = RedactURL(out.Webhooks[idx].URL)
+		if out.Webhooks[idx].SigningSecret != "" {
+			out.Webhooks[idx].SigningSecret = RedactedSecret
+		}
+		out.Webhooks[idx].Headers = redactValues(out.Webhooks[idx].Headers)
+	}
+	out.Emails = slices.Clone(r.Emails)
+	for idx := range out.Emails {
+		if out.Emails[idx].Pass != "" {
+			out.Emails[idx].Pass = RedactedSecret
+		}
+		out.Emails[idx].Headers = redactValues(out.Emails[idx].Headers)
+	}
+	out.Vars = redactValues(r.Vars)
+	return out
+}
+
+// RestoreSecrets replaces RedactedSecret values with the values of prev, so
+// a redacted receiver can be sent back unchanged. Targets are matched to
+// their previous version by identity, not position: webhooks by URL (or its
+// redacted form) and emails by host, user, and sender. A redacted value
+// without a previous value to restore is an error.
+func (r *ReceiverConfig) RestoreSecrets(prev ReceiverConfig) error {
+	var errs []error
+	for idx := range r.Webhooks {
+		if err := r.Webhooks[idx].restoreSecrets(prev.Webhooks); err != nil {
+			errs = append(errs, fmt.Errorf("webhooks[%d]: %w", idx, err))
+		}
+	}
+	for idx := range r.Emails {
+		if err := r.Emails[idx].restoreSecrets(prev.Emails); err != nil {
+			errs = append(errs, fmt.Errorf("emails[%d]: %w", idx, err))
+		}
+	}
+	if err := restoreValues(r.Vars, prev.Vars); err != nil {
+		errs = append(errs, fmt.Errorf("vars: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// restoreSecrets restores the redacted values of w from its previous version.
+func (w *WebhookConfig) restoreSecrets(prev []WebhookConfig) error {
+	var match *WebhookConfig
+	if strings.Contains(w.URL, RedactedSecret) {
+		for idx := range prev {
+			if RedactURL(prev[idx].URL) != w.URL {
+				continue
+			}
+			if match != nil {
+				return fmt.Errorf("url %q matches several webhooks; send the full url", w.URL)
+			}
+			match = &prev[idx]
+		}
+		if match == nil {
+			return fmt.Errorf("url %q matches no existing webhook", w.URL)
+		}
+		w.URL = match.URL
+	} else if idx := slices.IndexFunc(prev, func(p WebhookConfig) bool { return p.URL == w.URL }); idx >= 0 {
+		match = &prev[idx]
+	}
+
+	var prevSecret string
+	var prevHeaders map[string]string
+	if match != nil {
+		prevSecret, prevHeaders = match.SigningSecret, match.Headers
+	}
+	var errs []error
+	if w.SigningSecret == RedactedSecret {
+		if prevSecret == "" {
+			errs = append(errs, errors.New("signing_secret: no previous value to keep"))
+		}
+		w.SigningSecret = prevSecret
+	}
+	if err := restoreValues(w.Headers, prevHeaders); err != nil {
+		errs = append(errs, fmt.Errorf("headers: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// restoreSecrets restores the redacted values of e from its previous version.
+func (e *EmailConfig) restoreSecrets(prev []EmailConfig) error {
+	var match EmailConfig
+	idx := slices.IndexFunc(prev, func(p EmailConfig) bool {
+		return p.Host == e.Host && p.User == e.User && p.From == e.From
+	})
+	if idx >= 0 {
+		match = prev[idx]
+	}
+	var errs []error
+	if e.Pass == RedactedSecret {
+		if match.Pass == "" {
+			errs = append(errs, errors.New("pass: no previous value to keep"))
+		}
+		e.Pass = match.Pass
+	}
+	if err := restoreValues(e.Headers, match.Headers); err != nil {
+		errs = append(errs, fmt.Errorf("headers: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// Document converts a config value into a map keyed by its YAML field names,
+// for JSON responses that match the config file format.
+func Document(v any) (map[string]any, error) {
+	data, err := yamlMarshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := yamlUnmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// redactValues returns a copy of values with every value redacted.
+func redactValues[V any](values map[string]V) map[string]V {
+	if len(values) == 0 {
+		return values
+	}
+	redacted, _ := any(RedactedSecret).(V)
+	out := make(map[string]V, len(values))
+	for key := range values {
+		out[key] = redacted
+	}
+	return out
+}
+
+// restoreValues replaces redacted entries of values with those of prev.
+func restoreValues[V any](values, prev map[string]V) error {
+	var missing []string
+	for key, value := range values {
+		if !isRedacted(value) {
+			continue
+		}
+		previous, ok := prev[key]
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+		values[key] = previous
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return fmt.Errorf("no previous value to keep for %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// isRedacted reports whether value is RedactedSecret.
+func isRedacted(value any) bool {
+	text, ok := value.(string)
+	return ok && text == RedactedSecret
+}
+
+// RedactURL redacts the userinfo password, path, and query values of a URL,
+// keeping scheme and host to identify it. Values that are not URLs are
+// redacted entirely.
+func RedactURL(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return RedactedSecret
+	}
+	var b strings.Builder
+	b.WriteString(u.Scheme + "://")
+	if u.User != nil {
+		b.WriteString(u.User.Username())
+		if _, ok := u.User.Password(); ok {
+			b.WriteString(":" + RedactedSecret)
+		}
+		b.WriteString("@")
+	}
+	b.WriteString(u.Host)
+	if u.Path != "" && u.Path != "/" {
+		b.WriteString("/" + RedactedSecret)
+	}
+	if query := u.Query(); len(query) > 0 {
+		sep := "?"
+		for _, key := range slices.Sorted(maps.Keys(query)) {
+			b.WriteString(sep + url.QueryEscape(key) + "=" + RedactedSecret)
+			sep = "&"
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticOverlay Overlay
+
+func (o staticOverlay) Overlay() Overlay { return Overlay(o) }
+
+func TestOverlay(t *testing.T) {
+	t.Parallel()
+
+	t.Run("apply", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{Heartbeats: map[string]HeartbeatConfig{
+			"api":    {Interval: time.Minute},
+			"backup": {Interval: time.Hour},
+		}}
+		var o Overlay
+		o.DeleteHeartbeat("backup")
+		o.PutHeartbeat("api", HeartbeatConfig{Interval: 2 * time.Minute})
+		o.PutHeartbeat("cron", HeartbeatConfig{Interval: time.Second})
+		o.Apply(cfg)
+
+		assert.Equal(t, map[string]HeartbeatConfig{
+			"api":  {Interval: 2 * time.Minute},
+			"cron": {Interval: time.Second},
+		}, cfg.Heartbeats)
+	})
+
+	t.Run("put after delete", func(t *testing.T) {
+		t.Parallel()
+		var o Overlay
+		o.DeleteHeartbeat("api")
+		o.PutHeartbeat("api", HeartbeatConfig{Interval: time.Minute})
+		assert.Empty(t, o.DeletedHeartbeats)
+		assert.Contains(t, o.Heartbeats, "api")
+	})
+
+	t.Run("merged before validation", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+receivers:
+  ops:
+    webhooks:
+      - url: https://example.com
+heartbeats: {}
+`), 0o600))
+
+		_, err := LoadWithOptions(path, LoadOptions{})
+		require.Error(t, err)
+
+		cfg, err := LoadWithOptions(path, LoadOptions{Overlay: staticOverlay{
+			Heartbeats: map[string]HeartbeatConfig{
+				"api": {Interval: time.Minute, LateAfter: time.Minute, Receivers: []string{"ops"}},
+			},
+		}})
+		require.NoError(t, err)
+		assert.Contains(t, cfg.Heartbeats, "api")
+	})
+}
+
+func TestParseHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		hb, err := ParseHeartbeat([]byte(`{"interval":"1m","late_after":"30s","receivers":["ops"]}`))
+		require.NoError(t, err)
+		assert.Equal(t, time.Minute, hb.Interval)
+		assert.Equal(t, 30*time.Second, hb.LateAfter)
+		assert.Equal(t, []string{"ops"}, hb.Receivers)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		t.Parallel()
+		_, err := ParseHeartbeat([]byte(`{"interval":"1m","lateafter":"30s"}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "lateafter")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		_, err := ParseHeartbeat(nil)
+		require.EqualError(t, err, "empty heartbeat definition")
+	})
+}
+
+func TestReceiverSecrets(t *testing.T) {
+	t.Parallel()
+
+	rcv := ReceiverConfig{
+		Webhooks: []WebhookConfig{
+			{URL: "https://example.com", Headers: map[string]string{"Authorization": "Bearer s3cret"}},
+			{URL: "https://hooks.slack.com/services/T0/B0/xoxb?token=t0k3n"},
+		},
+		Emails: []EmailConfig{
+			{Host: "smtp", User: "ops", From: "a@example.com", To: []string{"b@example.com"}, Pass: "hunter2"},
+			{Host: "smtp2", User: "dev", From: "a@example.com", To: []string{"c@example.com"}, Pass: "swordfish"},
+		},
+		Vars: map[string]any{"token": "xoxb-1", "channel": "#ops"},
+	}
+
+	t.Run("redacts secrets", func(t *testing.T) {
+		t.Parallel()
+		redacted := rcv.Redacted()
+		assert.Equal(t, RedactedSecret, redacted.Webhooks[0].Headers["Authorization"])
+		assert.Equal(t, "https://example.com", redacted.Webhooks[0].URL)
+		assert.Equal(t, "https://hooks.slack.com/<redacted>?token=<redacted>", redacted.Webhooks[1].URL)
+		assert.Equal(t, RedactedSecret, redacted.Emails[0].Pass)
+		assert.Equal(t, map[string]any{"token": RedactedSecret, "channel": RedactedSecret}, redacted.Vars)
+		assert.Equal(t, "Bearer s3cret", rcv.Webhooks[0].Headers["Authorization"], "original is unchanged")
+		assert.Equal(t, "hunter2", rcv.Emails[0].Pass, "original is unchanged")
+		assert.Equal(t, "xoxb-1", rcv.Vars["token"], "original is unchanged")
+
+		doc, err := Document(redacted)
+		require.NoError(t, err)
+		assert.NotContains(t, fmt.Sprint(doc), "hunter2")
+		assert.NotContains(t, fmt.Sprint(doc), "xoxb")
+		assert.Contains(t, doc, "webhooks")
+	})
+
+	t.Run("restores reordered targets by identity", func(t *testing.T) {
+		t.Parallel()
+		update := rcv.Redacted()
+		slices.Reverse(update.Webhooks)
+		slices.Reverse(update.Emails)
+		require.NoError(t, update.RestoreSecrets(rcv))
+		assert.Equal(t, rcv.Webhooks[1].URL, update.Webhooks[0].URL)
+		assert.Equal(t, "Bearer s3cret", update.Webhooks[1].Headers["Authorization"])
+		assert.Equal(t, "swordfish", update.Emails[0].Pass)
+		assert.Equal(t, "hunter2", update.Emails[1].Pass)
+		assert.Equal(t, rcv.Vars, update.Vars)
+	})
+
+	t.Run("rejects redacted values without a previous target", func(t *testing.T) {
+		t.Parallel()
+		update := ReceiverConfig{
+			Webhooks: []WebhookConfig{{URL: "https://example.org", Headers: map[string]string{"Authorization": RedactedSecret}}},
+			Emails:   []EmailConfig{{Host: "smtp3", User: "ops", From: "a@example.com", Pass: RedactedSecret}},
+			Vars:     map[string]any{"api_key": RedactedSecret},
+		}
+		err := update.RestoreSecrets(rcv)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "webhooks[0]: headers: no previous value to keep for Authorization")
+		assert.Contains(t, err.Error(), "emails[0]: pass: no previous value to keep")
+		assert.Contains(t, err.Error(), "vars: no previous value to keep for api_key")
+	})
+
+	t.Run("keeps signing secrets with their webhook", func(t *testing.T) {
+		t.Parallel()
+		prev := ReceiverConfig{Webhooks: []WebhookConfig{
+			{URL: "https://a.example.com", SigningSecret: "secret-a"},
+			{URL: "https://b.example.com", SigningSecret: "secret-b"},
+		}}
+		update := prev.Redacted()
+		slices.Reverse(update.Webhooks)
+		require.NoError(t, update.RestoreSecrets(prev))
+		assert.Equal(t, "secret-b", update.Webhooks[0].SigningSecret)
+		assert.Equal(t, "secret-a", update.Webhooks[1].SigningSecret)
+
+		added := ReceiverConfig{Webhooks: append(update.Webhooks, WebhookConfig{URL: "https://c.example.com", SigningSecret: RedactedSecret})}
+		err := added.RestoreSecrets(prev)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "webhooks[2]: signing_secret: no previous value to keep")
+	})
+
+	t.Run("rejects ambiguous redacted urls", func(t *testing.T) {
+		t.Parallel()
+		prev := ReceiverConfig{Webhooks: []WebhookConfig{
+			{URL: "https://hooks.slack.com/services/a"},
+			{URL: "https://hooks.slack.com/services/b"},
+		}}
+		update := prev.Redacted()
+		err := update.RestoreSecrets(prev)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "matches several webhooks")
+	})
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Resolve applies heartbeat inheritance. Every heartbeat and template is
+// merged with the template named in extends, recursively, and finally with
+// defaults. Fields set on an entry win; unset fields are inherited. Resolving
+// an already resolved config changes nothing.
+func (c *Config) Resolve() error {
+	if c.Defaults != nil && c.Defaults.Extends != "" {
+		return errors.New("defaults must not use extends")
+	}
+	r := resolver{cfg: c, done: make(map[string]HeartbeatConfig, len(c.HeartbeatTemplates))}
+
+	templates := make(map[string]HeartbeatConfig, len(c.HeartbeatTemplates))
+	for name := range c.HeartbeatTemplates {
+		tmpl, err := r.template(name, nil)
+		if err != nil {
+			return fmt.Errorf("heartbeat_templates: %w", err)
+		}
+		templates[name] = tmpl
+	}
+	for id, hb := range c.Heartbeats {
+		resolved, err := r.resolve(hb, nil)
+		if err != nil {
+			return fmt.Errorf("heartbeat %q: %w", id, err)
+		}
+		c.Heartbeats[id] = resolved
+	}
+	if c.HeartbeatTemplates != nil {
+		c.HeartbeatTemplates = templates
+	}
+	return nil
+}
+
+// resolver memoizes resolved templates.
+type resolver struct {
+	cfg  *Config
+	done map[string]HeartbeatConfig
+}
+
+// resolve merges hb with its parent: the extended template or the defaults.
+// chain lists the templates being resolved, to detect cycles.
+func (r *resolver) resolve(hb HeartbeatConfig, chain []string) (HeartbeatConfig, error) {
+	var parent HeartbeatConfig
+	if r.cfg.Defaults != nil {
+		parent = *r.cfg.Defaults
+	}
+	if hb.Extends != "" {
+		// Templates already include the defaults.
+		tmpl, err := r.template(hb.Extends, chain)
+		if err != nil {
+			return HeartbeatConfig{}, err
+		}
+		parent = tmpl
+	}
+	return inherit(hb, parent), nil
+}
+
+// template returns the resolved template name.
+func (r *resolver) template(name string, chain []string) (HeartbeatConfig, error) {
+	if tmpl, ok := r.done[name]; ok {
+		return tmpl, nil
+	}
+	if slices.Contains(chain, name) {
+		return HeartbeatConfig{}, fmt.Errorf("extends cycle %s", strings.Join(append(chain, name), " -> "))
+	}
+	tmpl, ok := r.cfg.HeartbeatTemplates[name]
+	if !ok {
+		return HeartbeatConfig{}, fmt.Errorf("extends unknown template %q", name)
+	}
+	resolved, err := r.resolve(tmpl, append(chain, name))
+	if err != nil {
+		return HeartbeatConfig{}, err
+	}
+	r.done[name] = resolved
+	return resolved, nil
+}
+
+// inherit fills the unset fields of child from parent. Pointers and slices
+// count as set when non-nil, so `alert_on_late: false` or `metrics: []`
+// override the parent.
+func inherit(child, parent HeartbeatConfig) HeartbeatConfig {
+	out := child
+	dst := reflect.ValueOf(&out).Elem()
+	src := reflect.ValueOf(parent)
+	for i := range dst.NumField() {
+		if dst.Field(i).IsZero() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+	out.Extends = child.Extends
+	return out
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults and profile chain", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+receivers:
+  ops:
+    webhooks:
+      - url: https://example.com
+  pager:
+    webhooks:
+      - url: https://example.org
+defaults:
+  receivers: ["ops"]
+  subject_tmpl: "[{{ .Title }}] {{ .Status }}"
+  alert_on_recovery: true
+heartbeat_templates:
+  nightly:
+    interval: 24h
+    late_after: 1h
+  nightly-critical:
+    extends: nightly
+    receivers: ["ops", "pager"]
+heartbeats:
+  backup:
+    extends: nightly-critical
+    title: Backup
+  api:
+    interval: 1m
+    late_after: 30s
+    alert_on_recovery: false
+  quiet:
+    extends: nightly
+    receivers: ["pager"]
+`), 0o600))
+
+		cfg, err := LoadWithOptions(path, LoadOptions{})
+		require.NoError(t, err)
+
+		backup := cfg.Heartbeats["backup"]
+		assert.Equal(t, "nightly-critical", backup.Extends)
+		assert.Equal(t, "Backup", backup.Title)
+		assert.Equal(t, 24*time.Hour, backup.Interval)
+		assert.Equal(t, time.Hour, backup.LateAfter)
+		assert.Equal(t, []string{"ops", "pager"}, backup.Receivers)
+		assert.Equal(t, "[{{ .Title }}] {{ .Status }}", backup.SubjectTmpl)
+		require.NotNil(t, backup.AlertOnRecovery)
+		assert.True(t, *backup.AlertOnRecovery)
+
+		api := cfg.Heartbeats["api"]
+		assert.Equal(t, []string{"ops"}, api.Receivers)
+		require.NotNil(t, api.AlertOnRecovery)
+		assert.False(t, *api.AlertOnRecovery)
+
+		assert.Equal(t, []string{"pager"}, cfg.Heartbeats["quiet"].Receivers)
+		assert.Equal(t, []string{"ops"}, cfg.HeartbeatTemplates["nightly"].Receivers)
+	})
+
+	t.Run("idempotent", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{
+			Defaults:           &HeartbeatConfig{LateAfter: time.Minute},
+			HeartbeatTemplates: map[string]HeartbeatConfig{"base": {Interval: time.Hour}},
+			Heartbeats:         map[string]HeartbeatConfig{"api": {Extends: "base"}},
+		}
+		require.NoError(t, cfg.Resolve())
+		first := cfg.Heartbeats["api"]
+		require.NoError(t, cfg.Resolve())
+		assert.Equal(t, first, cfg.Heartbeats["api"])
+		assert.Equal(t, HeartbeatConfig{Extends: "base", Interval: time.Hour, LateAfter: time.Minute}, first)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{Heartbeats: map[string]HeartbeatConfig{"api": {Extends: "ghost"}}}
+		require.EqualError(t, cfg.Resolve(), `heartbeat "api": extends unknown template "ghost"`)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{HeartbeatTemplates: map[string]HeartbeatConfig{
+			"a": {Extends: "b"},
+			"b": {Extends: "a"},
+		}}
+		err := cfg.Resolve()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "extends cycle")
+	})
+
+	t.Run("defaults cannot extend", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{Defaults: &HeartbeatConfig{Extends: "base"}}
+		require.EqualError(t, cfg.Resolve(), "defaults must not use extends")
+	})
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// statusPattern matches a status code like 429 or a class like 5xx.
+var statusPattern = regexp.MustCompile(`^[1-5]([0-9]{2}|xx)$`)
+
+// RetriesStatus reports whether a webhook response with the given status
+// code is retried. Patterns are exact codes or classes like 5xx; without
+// patterns, 5xx and 429 are retried.
+func (r RetryConfig) RetriesStatus(code int) bool {
+	patterns := r.RetryOn
+	if len(patterns) == 0 {
+		patterns = []string{"5xx", "429"}
+	}
+	status := strconv.Itoa(code)
+	for _, pattern := range patterns {
+		if strings.EqualFold(pattern, status) {
+			return true
+		}
+		if strings.HasSuffix(strings.ToLower(pattern), "xx") && len(status) == 3 && pattern[0] == status[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRetry validates a receiver retry policy.
+func validateRetry(retry RetryConfig) error {
+	if retry.Count != nil && *retry.Count < 0 {
+		return errors.New("count must be >= 0")
+	}
+	if retry.Delay < 0 || retry.MaxDelay < 0 {
+		return errors.New("delay and max_delay must be >= 0")
+	}
+	switch retry.Backoff {
+	case "", BackoffFixed, BackoffExponential:
+	default:
+		return fmt.Errorf("backoff %q must be %s or %s", retry.Backoff, BackoffFixed, BackoffExponential)
+	}
+	if retry.Jitter < 0 || retry.Jitter > 1 {
+		return errors.New("jitter must be between 0 and 1")
+	}
+	for idx, pattern := range retry.RetryOn {
+		if !statusPattern.MatchString(strings.ToLower(pattern)) {
+			return fmt.Errorf("retry_on[%d] %q must be a status code like 429 or a class like 5xx", idx, pattern)
+		}
+	}
+	return nil
+}
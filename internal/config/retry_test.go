@@ -0,0 +1,60 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/containeroo/heartbeats/internal/utils"
+)
+
+func TestRetriesStatus(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults retry 5xx and 429", func(t *testing.T) {
+		t.Parallel()
+		var retry RetryConfig
+		assert.True(t, retry.RetriesStatus(503))
+		assert.True(t, retry.RetriesStatus(429))
+		assert.False(t, retry.RetriesStatus(400))
+		assert.False(t, retry.RetriesStatus(404))
+	})
+
+	t.Run("custom codes and classes", func(t *testing.T) {
+		t.Parallel()
+		retry := RetryConfig{RetryOn: []string{"502", "4XX"}}
+		assert.True(t, retry.RetriesStatus(502))
+		assert.True(t, retry.RetriesStatus(404))
+		assert.False(t, retry.RetriesStatus(500))
+	})
+}
+
+func TestValidateRetry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		retry RetryConfig
+		err   string
+	}{
+		{name: "defaults", retry: RetryConfig{}},
+		{name: "full policy", retry: RetryConfig{Count: utils.ToPtr(5), Delay: time.Second, Backoff: BackoffExponential, MaxDelay: time.Minute, Jitter: 0.2, RetryOn: []string{"5xx", "429"}}},
+		{name: "no retries", retry: RetryConfig{Count: utils.ToPtr(0)}},
+		{name: "negative count", retry: RetryConfig{Count: utils.ToPtr(-1)}, err: "count must be >= 0"},
+		{name: "unknown backoff", retry: RetryConfig{Backoff: "linear"}, err: `backoff "linear" must be fixed or exponential`},
+		{name: "jitter out of range", retry: RetryConfig{Jitter: 1.5}, err: "jitter must be between 0 and 1"},
+		{name: "bad status", retry: RetryConfig{RetryOn: []string{"5x"}}, err: `retry_on[0] "5x" must be a status code like 429 or a class like 5xx`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateRetry(tt.retry)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
@@ -1,8 +1,24 @@
 package config
 
-import "gopkg.in/yaml.v3"
+import (
+	"bytes"
+
+	"gopkg.in/yaml.v3"
+)
 
 // yamlUnmarshal unmarshals YAML data into a value.
 func yamlUnmarshal(data []byte, v any) error {
 	return yaml.Unmarshal(data, v)
 }
+
+// yamlMarshal marshals a value into YAML.
+func yamlMarshal(v any) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+// yamlUnmarshalStrict unmarshals YAML data into a value and rejects unknown fields.
+func yamlUnmarshalStrict(data []byte, v any) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	return dec.Decode(v)
+}
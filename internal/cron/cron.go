@@ -0,0 +1,228 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLookahead bounds the search for the next activation time.
+const maxLookahead = 5 * 366 * 24 * time.Hour
+
+// Schedule is a parsed five-field cron expression.
+type Schedule struct {
+	minute  fieldSet // Allowed minutes (0-59).
+	hour    fieldSet // Allowed hours (0-23).
+	dom     fieldSet // Allowed days of month (1-31).
+	month   fieldSet // Allowed months (1-12).
+	dow     fieldSet // Allowed days of week (0-6, Sunday = 0).
+	domStar bool     // Day of month was "*".
+	dowStar bool     // Day of week was "*".
+}
+
+// fieldSet is a bitmask of allowed values for a cron field.
+type fieldSet uint64
+
+// has reports whether v is part of the set.
+func (f fieldSet) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// fieldBounds describes the value range of a cron field.
+type fieldBounds struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteBounds = fieldBounds{name: "minute", min: 0, max: 59}
+	hourBounds   = fieldBounds{name: "hour", min: 0, max: 23}
+	domBounds    = fieldBounds{name: "day of month", min: 1, max: 31}
+	monthBounds  = fieldBounds{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = fieldBounds{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors maps predefined schedules to their cron expressions.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a standard five-field cron expression
+// (minute hour day-of-month month day-of-week) or a predefined
+// descriptor such as @daily or @weekly.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Schedule{}, fmt.Errorf("empty cron expression")
+	}
+	if strings.HasPrefix(spec, "@") {
+		expanded, ok := descriptors[strings.ToLower(spec)]
+		if !ok {
+			return Schedule{}, fmt.Errorf("unknown cron descriptor %q", spec)
+		}
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("cron expression %q must have 5 fields, got %d", spec, len(fields))
+	}
+
+	var (
+		s   Schedule
+		err error
+	)
+	if s.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return Schedule{}, err
+	}
+	if s.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return Schedule{}, err
+	}
+	if s.dom, err = parseField(fields[2], domBounds); err != nil {
+		return Schedule{}, err
+	}
+	if s.month, err = parseField(fields[3], monthBounds); err != nil {
+		return Schedule{}, err
+	}
+	if s.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return Schedule{}, err
+	}
+	// Both 0 and 7 mean Sunday.
+	if s.dow.has(7) {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+	return s, nil
+}
+
+// Next returns the first activation time strictly after t, in t's location.
+// It returns the zero time when no activation exists within the lookahead window.
+func (s Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxLookahead)
+	for t.Before(limit) {
+		if !s.month.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.hour.has(t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.minute.has(t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the cron day-of-month/day-of-week rule: when both are
+// restricted, either may match; otherwise the restricted one must match.
+func (s Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom.has(t.Day())
+	dowMatch := s.dow.has(int(t.Weekday()))
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dowMatch
+	case s.dowStar:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// parseField parses a comma-separated list of values, ranges and steps.
+func parseField(field string, bounds fieldBounds) (fieldSet, error) {
+	var set fieldSet
+	for part := range strings.SplitSeq(field, ",") {
+		partSet, err := parsePart(part, bounds)
+		if err != nil {
+			return 0, err
+		}
+		set |= partSet
+	}
+	return set, nil
+}
+
+// parsePart parses a single "*", "a", "a-b" term with an optional "/step".
+func parsePart(part string, bounds fieldBounds) (fieldSet, error) {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+	step := 1
+	if hasStep {
+		n, err := strconv.Atoi(stepPart)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid %s step %q", bounds.name, stepPart)
+		}
+		step = n
+	}
+
+	lo, hi := bounds.min, bounds.max
+	switch {
+	case rangePart == "*":
+	case strings.Contains(rangePart, "-"):
+		from, to, _ := strings.Cut(rangePart, "-")
+		var err error
+		if lo, err = parseValue(from, bounds); err != nil {
+			return 0, err
+		}
+		if hi, err = parseValue(to, bounds); err != nil {
+			return 0, err
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("invalid %s range %q", bounds.name, rangePart)
+		}
+	default:
+		v, err := parseValue(rangePart, bounds)
+		if err != nil {
+			return 0, err
+		}
+		lo = v
+		if !hasStep {
+			hi = v
+		}
+	}
+
+	var set fieldSet
+	for v := lo; v <= hi; v += step {
+		set |= 1 << uint(v)
+	}
+	return set, nil
+}
+
+// parseValue parses a numeric or named field value and checks its bounds.
+func parseValue(value string, bounds fieldBounds) (int, error) {
+	if n, ok := bounds.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value %q", bounds.name, value)
+	}
+	if n < bounds.min || n > bounds.max {
+		return 0, fmt.Errorf("%s value %d out of range [%d-%d]", bounds.name, n, bounds.min, bounds.max)
+	}
+	return n, nil
+}
@@ -0,0 +1,80 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid expressions", func(t *testing.T) {
+		t.Parallel()
+		for _, spec := range []string{
+			"* * * * *",
+			"0 8 * * 1-5",
+			"*/15 0,12 1 jan-jun sun",
+			"@daily",
+			"@Weekly",
+		} {
+			_, err := Parse(spec)
+			assert.NoError(t, err, spec)
+		}
+	})
+
+	t.Run("invalid expressions", func(t *testing.T) {
+		t.Parallel()
+		for _, spec := range []string{
+			"",
+			"* * * *",
+			"60 * * * *",
+			"* 24 * * *",
+			"* * 0 * *",
+			"*/0 * * * *",
+			"5-1 * * * *",
+			"@sometimes",
+		} {
+			_, err := Parse(spec)
+			assert.Error(t, err, spec)
+		}
+	})
+}
+
+func TestScheduleNext(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, time.March, 4, 10, 17, 30, 0, time.UTC) // Wednesday
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.March, 4, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.March, 4, 10, 30, 0, 0, time.UTC)},
+		{"0 8 * * *", time.Date(2026, time.March, 5, 8, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1", time.Date(2026, time.March, 9, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 6 15 * 5", time.Date(2026, time.March, 6, 6, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.spec, func(t *testing.T) {
+			t.Parallel()
+			sched, err := Parse(tc.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, sched.Next(base))
+		})
+	}
+
+	t.Run("impossible date", func(t *testing.T) {
+		t.Parallel()
+		sched, err := Parse("0 0 31 2 *")
+		require.NoError(t, err)
+		assert.True(t, sched.Next(base).IsZero())
+	})
+}
@@ -0,0 +1,272 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	kit "github.com/containeroo/notifykit/notify"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/cron"
+	htypes "github.com/containeroo/heartbeats/internal/heartbeat/types"
+	"github.com/containeroo/heartbeats/internal/history"
+	"github.com/containeroo/heartbeats/internal/logging"
+	"github.com/containeroo/heartbeats/internal/notify"
+)
+
+const (
+	defaultPeriod = 24 * time.Hour
+	statusDigest  = "digest"
+)
+
+// Store exposes read access to heartbeat state.
+type Store interface {
+	All() []*htypes.Heartbeat
+}
+
+// Scheduler renders and enqueues digest reports on their cron schedules.
+type Scheduler struct {
+	mu         sync.Mutex
+	digests    map[string]config.DigestConfig
+	updated    chan struct{}
+	templateFS fs.FS
+	store      Store
+	history    history.Recorder
+	notifier   kit.Notifier
+	logger     *slog.Logger
+}
+
+// NewScheduler constructs a digest Scheduler.
+func NewScheduler(
+	templateFS fs.FS,
+	store Store,
+	historyStore history.Recorder,
+	notifier kit.Notifier,
+	logger *slog.Logger,
+) *Scheduler {
+	return &Scheduler{
+		digests:    make(map[string]config.DigestConfig),
+		updated:    make(chan struct{}, 1),
+		templateFS: templateFS,
+		store:      store,
+		history:    historyStore,
+		notifier:   notifier,
+		logger:     logger,
+	}
+}
+
+// SetDigests replaces the configured digests and reschedules them.
+func (s *Scheduler) SetDigests(digests map[string]config.DigestConfig) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.digests = make(map[string]config.DigestConfig, len(digests))
+	for name, digest := range digests {
+		s.digests[name] = digest
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.updated <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs the scheduling loop until ctx is canceled.
+func (s *Scheduler) Start(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	go s.run(ctx)
+}
+
+// run waits for the earliest due digest, sends it, and reschedules.
+func (s *Scheduler) run(ctx context.Context) {
+	var timer *time.Timer
+	for {
+		now := time.Now()
+		due := s.nextRuns(now)
+		var (
+			next   time.Time
+			timerC <-chan time.Time
+		)
+		for _, at := range due {
+			if next.IsZero() || at.Before(next) {
+				next = at
+			}
+		}
+		if !next.IsZero() {
+			if timer == nil {
+				timer = time.NewTimer(next.Sub(now))
+			} else {
+				timer.Reset(next.Sub(now))
+			}
+			timerC = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-s.updated:
+			if timer != nil && !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		case fired := <-timerC:
+			for name, at := range due {
+				if at.After(fired) {
+					continue
+				}
+				if err := s.Send(ctx, name, at); err != nil {
+					s.logger.Error("Digest delivery failed",
+						"event", logging.EventDigestFailed.String(),
+						"digest", name,
+						"err", err,
+					)
+				}
+			}
+		}
+	}
+}
+
+// nextRuns returns the next activation time per digest.
+func (s *Scheduler) nextRuns(now time.Time) map[string]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]time.Time, len(s.digests))
+	for name, digest := range s.digests {
+		sched, err := cron.Parse(digest.Schedule)
+		if err != nil {
+			continue
+		}
+		loc, err := location(digest.Timezone)
+		if err != nil {
+			continue
+		}
+		if next := sched.Next(now.In(loc)); !next.IsZero() {
+			out[name] = next
+		}
+	}
+	return out
+}
+
+// Send renders the named digest for the window ending at now and enqueues it.
+func (s *Scheduler) Send(ctx context.Context, name string, now time.Time) error {
+	s.mu.Lock()
+	digest, ok := s.digests[name]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("digest %q not found", name)
+	}
+
+	title := strings.TrimSpace(digest.Title)
+	if title == "" {
+		title = name
+	}
+	period := digest.Period
+	if period <= 0 {
+		period = defaultPeriod
+	}
+
+	var events []history.Event
+	if s.history != nil {
+		events = s.history.List()
+	}
+	report := BuildReport(name, title, now.Add(-period), now, s.store.All(), events)
+
+	body, err := s.render(digest.Template, report)
+	if err != nil {
+		return err
+	}
+
+	event := notify.NewEvent(
+		name,
+		title,
+		statusDigest,
+		body,
+		0,
+		now,
+		period,
+		0,
+		notify.DigestReceiverIDs(name, digest.Receivers),
+	)
+	id, err := s.notifier.Enqueue(ctx, event)
+	if err != nil {
+		return fmt.Errorf("enqueue digest: %w", err)
+	}
+
+	s.logger.Info("Digest queued",
+		"event", logging.EventDigestSent.String(),
+		"digest", name,
+		"queue_id", id,
+		"heartbeats", report.Total,
+		"incidents", report.Incidents,
+	)
+	if s.history != nil {
+		s.history.Add(history.Event{
+			Time:    now.UTC(),
+			Type:    history.EventDigestSent.String(),
+			Message: title,
+			Fields: map[string]any{
+				"digest":    name,
+				"from":      report.From.UTC().Format(time.RFC3339),
+				"to":        report.To.UTC().Format(time.RFC3339),
+				"incidents": report.Incidents,
+			},
+		})
+	}
+	return nil
+}
+
+// render executes the digest report template.
+func (s *Scheduler) render(source string, report Report) (string, error) {
+	text, err := loadTemplate(s.templateFS, source)
+	if err != nil {
+		return "", fmt.Errorf("load digest template: %w", err)
+	}
+	tmpl, err := template.New("digest").Funcs(notify.FuncMap()).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse digest template: %w", err)
+	}
+	if err := notify.AddPartials(tmpl, s.templateFS); err != nil {
+		return "", fmt.Errorf("parse digest template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, report); err != nil {
+		return "", fmt.Errorf("render digest template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// loadTemplate reads a built-in template, a template directory file or a
+// template file path.
+func loadTemplate(templateFS fs.FS, source string) (string, error) {
+	source = strings.TrimSpace(source)
+	switch strings.ToLower(source) {
+	case "", "default", "digest":
+		source = "builtin:digest"
+	}
+	return notify.ReadTemplate(templateFS, source)
+}
+
+// location resolves a digest timezone, defaulting to UTC.
+func location(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
@@ -0,0 +1,144 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	kit "github.com/containeroo/notifykit/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	htypes "github.com/containeroo/heartbeats/internal/heartbeat/types"
+	"github.com/containeroo/heartbeats/internal/history"
+	appnotify "github.com/containeroo/heartbeats/internal/notify"
+	"github.com/containeroo/heartbeats/internal/runner"
+)
+
+type captureNotifier struct {
+	events []*appnotify.Event
+}
+
+func (c *captureNotifier) Enqueue(_ context.Context, n kit.Notification) (string, error) {
+	if event, ok := n.(*appnotify.Event); ok {
+		c.events = append(c.events, event)
+	}
+	return n.ID(), nil
+}
+
+type fakeStore struct {
+	heartbeats []*htypes.Heartbeat
+}
+
+func (f fakeStore) All() []*htypes.Heartbeat { return f.heartbeats }
+
+func newTestScheduler(t *testing.T) (*Scheduler, *captureNotifier, *history.Store) {
+	t.Helper()
+	hb := &htypes.Heartbeat{ID: "api", Title: "API", State: runner.NewState()}
+	hb.State.MarkOK()
+
+	notifier := &captureNotifier{}
+	hist := history.NewStore(10)
+	templateFS := os.DirFS(filepath.Join("..", "..", "templates"))
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	return NewScheduler(templateFS, fakeStore{heartbeats: []*htypes.Heartbeat{hb}}, hist, notifier, logger), notifier, hist
+}
+
+func TestSchedulerSend(t *testing.T) {
+	t.Parallel()
+
+	t.Run("enqueues rendered digest", func(t *testing.T) {
+		t.Parallel()
+
+		sched, notifier, hist := newTestScheduler(t)
+		sched.SetDigests(map[string]config.DigestConfig{
+			"daily": {
+				Title:     "Daily report",
+				Schedule:  "0 8 * * *",
+				Receivers: []string{"ops"},
+			},
+		})
+
+		now := time.Date(2026, time.March, 4, 8, 0, 0, 0, time.UTC)
+		require.NoError(t, sched.Send(context.Background(), "daily", now))
+
+		require.Len(t, notifier.events, 1)
+		event := notifier.events[0]
+		assert.Equal(t, "digest", event.StatusValue)
+		assert.Equal(t, "Daily report", event.TitleValue)
+		assert.Equal(t, 24*time.Hour, event.Interval)
+		assert.Equal(t, []kit.ReceiverID{"digest.daily.receiver.ops"}, event.ReceiverIDs())
+		assert.Contains(t, event.Body, "1/1 heartbeats ok")
+		assert.Contains(t, event.Body, "API (api): ok")
+
+		events := hist.List()
+		require.Len(t, events, 1)
+		assert.Equal(t, history.EventDigestSent.String(), events[0].Type)
+	})
+
+	t.Run("custom template file", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "digest.tmpl")
+		require.NoError(t, os.WriteFile(path, []byte(`{{ .Name }}: {{ len .Heartbeats }}`), 0o600))
+
+		sched, notifier, _ := newTestScheduler(t)
+		sched.SetDigests(map[string]config.DigestConfig{
+			"weekly": {Schedule: "@weekly", Period: 7 * 24 * time.Hour, Template: path, Receivers: []string{"ops"}},
+		})
+
+		require.NoError(t, sched.Send(context.Background(), "weekly", time.Now()))
+		require.Len(t, notifier.events, 1)
+		assert.Equal(t, "weekly: 1", notifier.events[0].Body)
+		assert.Equal(t, "weekly", notifier.events[0].TitleValue)
+	})
+
+	t.Run("template directory with partials", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "digest.tmpl"), []byte(`{{ template "header" . }} {{ len .Heartbeats }}`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "partials.tmpl"), []byte(`{{ define "header" }}[{{ .Name }}]{{ end }}`), 0o600))
+
+		notifier := &captureNotifier{}
+		templateFS := appnotify.TemplateFS(os.DirFS(filepath.Join("..", "..", "templates")), dir)
+		hb := &htypes.Heartbeat{ID: "api", Title: "API", State: runner.NewState()}
+		sched := NewScheduler(templateFS, fakeStore{heartbeats: []*htypes.Heartbeat{hb}}, history.NewStore(10), notifier, slog.New(slog.DiscardHandler))
+		sched.SetDigests(map[string]config.DigestConfig{
+			"daily": {Schedule: "@daily", Receivers: []string{"ops"}},
+		})
+
+		require.NoError(t, sched.Send(context.Background(), "daily", time.Now()))
+		require.Len(t, notifier.events, 1)
+		assert.Equal(t, "[daily] 1", notifier.events[0].Body)
+	})
+
+	t.Run("unknown digest", func(t *testing.T) {
+		t.Parallel()
+
+		sched, _, _ := newTestScheduler(t)
+		err := sched.Send(context.Background(), "missing", time.Now())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+	})
+}
+
+func TestSchedulerNextRuns(t *testing.T) {
+	t.Parallel()
+
+	sched, _, _ := newTestScheduler(t)
+	sched.SetDigests(map[string]config.DigestConfig{
+		"daily":  {Schedule: "0 8 * * *", Timezone: "Europe/Zurich"},
+		"broken": {Schedule: "not a cron"},
+	})
+
+	now := time.Date(2026, time.March, 4, 6, 0, 0, 0, time.UTC)
+	runs := sched.nextRuns(now)
+	require.Len(t, runs, 1)
+	assert.Equal(t, time.Date(2026, time.March, 4, 7, 0, 0, 0, time.UTC), runs["daily"].UTC())
+}
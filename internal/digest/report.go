@@ -0,0 +1,145 @@
+package digest
+
+import (
+	"sort"
+	"time"
+
+	htypes "github.com/containeroo/heartbeats/internal/heartbeat/types"
+	"github.com/containeroo/heartbeats/internal/history"
+	"github.com/containeroo/heartbeats/internal/runner"
+)
+
+// Report is the template context for digest reports.
+type Report struct {
+	Name       string            // Digest name.
+	Title      string            // Human-friendly digest title.
+	From       time.Time         // Start of the reporting window.
+	To         time.Time         // End of the reporting window.
+	Period     time.Duration     // Length of the reporting window.
+	Heartbeats []HeartbeatReport // Per-heartbeat summaries, sorted by id.
+	Total      int               // Number of heartbeats.
+	Healthy    int               // Heartbeats currently in the ok stage.
+	Incidents  int               // Missing incidents across all heartbeats.
+}
+
+// HeartbeatReport summarizes a single heartbeat over the reporting window.
+type HeartbeatReport struct {
+	ID        string        // Heartbeat identifier.
+	Title     string        // Human-friendly title.
+	Status    string        // Current stage.
+	LastBump  time.Time     // Last received heartbeat.
+	Pings     int           // Heartbeats received in the window.
+	Late      int           // Transitions into late in the window.
+	Incidents int           // Transitions into missing in the window.
+	Downtime  time.Duration // Time spent missing in the window.
+	Uptime    float64       // Percentage of the window not spent missing.
+}
+
+// BuildReport summarizes heartbeats and their history between from and to.
+func BuildReport(name, title string, from, to time.Time, heartbeats []*htypes.Heartbeat, events []history.Event) Report {
+	byID := make(map[string][]history.Event)
+	for _, ev := range events {
+		if ev.HeartbeatID == "" {
+			continue
+		}
+		byID[ev.HeartbeatID] = append(byID[ev.HeartbeatID], ev)
+	}
+
+	report := Report{
+		Name:   name,
+		Title:  title,
+		From:   from,
+		To:     to,
+		Period: to.Sub(from),
+	}
+	for _, hb := range heartbeats {
+		if hb == nil || hb.State == nil {
+			continue
+		}
+		item := buildHeartbeatReport(hb, from, to, byID[hb.ID])
+		report.Heartbeats = append(report.Heartbeats, item)
+		report.Total++
+		report.Incidents += item.Incidents
+		if item.Status == runner.StageOK.String() {
+			report.Healthy++
+		}
+	}
+	sort.Slice(report.Heartbeats, func(i, j int) bool {
+		return report.Heartbeats[i].ID < report.Heartbeats[j].ID
+	})
+	return report
+}
+
+// buildHeartbeatReport walks the heartbeat transitions to compute counters
+// and the time spent missing within the window.
+func buildHeartbeatReport(hb *htypes.Heartbeat, from, to time.Time, events []history.Event) HeartbeatReport {
+	snap := hb.State.Snapshot()
+	item := HeartbeatReport{
+		ID:       hb.ID,
+		Title:    hb.Title,
+		Status:   snap.Stage.String(),
+		LastBump: snap.LastSeen,
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	stage := initialStage(events, from, snap.Stage.String())
+	cursor := from
+	for _, ev := range events {
+		if ev.Time.Before(from) || ev.Time.After(to) {
+			continue
+		}
+		switch ev.Type {
+		case history.EventHeartbeatReceived.String():
+			item.Pings++
+		case history.EventHeartbeatTransition.String():
+			next, _ := ev.Fields["to"].(string)
+			if stage == runner.StageMissing.String() {
+				item.Downtime += ev.Time.Sub(cursor)
+			}
+			switch next {
+			case runner.StageLate.String():
+				item.Late++
+			case runner.StageMissing.String():
+				item.Incidents++
+			}
+			stage = next
+			cursor = ev.Time
+		}
+	}
+	if stage == runner.StageMissing.String() {
+		item.Downtime += to.Sub(cursor)
+	}
+
+	item.Uptime = 100
+	if period := to.Sub(from); period > 0 {
+		item.Uptime = 100 * (1 - float64(item.Downtime)/float64(period))
+	}
+	return item
+}
+
+// initialStage returns the stage a heartbeat was in at the window start.
+func initialStage(events []history.Event, from time.Time, current string) string {
+	stage := ""
+	for _, ev := range events {
+		if ev.Type != history.EventHeartbeatTransition.String() {
+			continue
+		}
+		if ev.Time.Before(from) {
+			stage, _ = ev.Fields["to"].(string)
+			continue
+		}
+		if stage == "" {
+			// No transition before the window; the first one in it tells us
+			// where the heartbeat came from.
+			stage, _ = ev.Fields["from"].(string)
+		}
+		break
+	}
+	if stage == "" {
+		return current
+	}
+	return stage
+}
@@ -0,0 +1,91 @@
+package digest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	htypes "github.com/containeroo/heartbeats/internal/heartbeat/types"
+	"github.com/containeroo/heartbeats/internal/history"
+	"github.com/containeroo/heartbeats/internal/runner"
+)
+
+func transition(at time.Time, id, from, to string) history.Event {
+	return history.Event{
+		Time:        at,
+		Type:        history.EventHeartbeatTransition.String(),
+		HeartbeatID: id,
+		Status:      to,
+		Fields:      map[string]any{"from": from, "to": to},
+	}
+}
+
+func received(at time.Time, id string) history.Event {
+	return history.Event{
+		Time:        at,
+		Type:        history.EventHeartbeatReceived.String(),
+		HeartbeatID: id,
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	t.Parallel()
+
+	to := time.Date(2026, time.March, 4, 8, 0, 0, 0, time.UTC)
+	from := to.Add(-10 * time.Hour)
+
+	api := &htypes.Heartbeat{ID: "api", Title: "API", State: runner.NewState()}
+	api.State.MarkOK()
+	db := &htypes.Heartbeat{ID: "db", Title: "DB", State: runner.NewState()}
+	db.State.MarkMissing(to)
+
+	events := []history.Event{
+		// Before the window: db was already missing.
+		transition(from.Add(-time.Hour), "db", "late", "missing"),
+		received(from.Add(time.Hour), "api"),
+		transition(from.Add(2*time.Hour), "api", "ok", "late"),
+		transition(from.Add(3*time.Hour), "api", "late", "missing"),
+		transition(from.Add(4*time.Hour), "api", "missing", "ok"),
+		received(from.Add(4*time.Hour), "api"),
+		transition(from.Add(5*time.Hour), "db", "missing", "ok"),
+		transition(from.Add(8*time.Hour), "db", "ok", "missing"),
+	}
+
+	report := BuildReport("daily", "Daily", from, to, []*htypes.Heartbeat{db, api}, events)
+
+	require.Equal(t, 2, report.Total)
+	assert.Equal(t, 1, report.Healthy)
+	assert.Equal(t, 2, report.Incidents)
+	require.Len(t, report.Heartbeats, 2)
+
+	apiReport := report.Heartbeats[0]
+	assert.Equal(t, "api", apiReport.ID)
+	assert.Equal(t, 2, apiReport.Pings)
+	assert.Equal(t, 1, apiReport.Late)
+	assert.Equal(t, 1, apiReport.Incidents)
+	assert.Equal(t, time.Hour, apiReport.Downtime)
+	assert.InDelta(t, 90.0, apiReport.Uptime, 0.001)
+
+	dbReport := report.Heartbeats[1]
+	assert.Equal(t, "db", dbReport.ID)
+	assert.Equal(t, "missing", dbReport.Status)
+	assert.Equal(t, 1, dbReport.Incidents)
+	assert.Equal(t, 7*time.Hour, dbReport.Downtime)
+	assert.InDelta(t, 30.0, dbReport.Uptime, 0.001)
+}
+
+func TestBuildReportWithoutHistory(t *testing.T) {
+	t.Parallel()
+
+	to := time.Now()
+	hb := &htypes.Heartbeat{ID: "api", State: runner.NewState()}
+	hb.State.MarkOK()
+
+	report := BuildReport("daily", "Daily", to.Add(-time.Hour), to, []*htypes.Heartbeat{hb}, nil)
+
+	require.Len(t, report.Heartbeats, 1)
+	assert.Zero(t, report.Heartbeats[0].Downtime)
+	assert.Equal(t, 100.0, report.Heartbeats[0].Uptime)
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package filewatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// watchMask covers writes, atomic renames, and the symlink swaps Kubernetes
+// uses to update mounted ConfigMaps and Secrets.
+const watchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ATTRIB | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// notify signals events in dirs until ctx is canceled.
+func notify(ctx context.Context, dirs []string) (<-chan struct{}, error) {
+	if len(dirs) == 0 {
+		return nil, errors.New("no directories to watch")
+	}
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify init: %w", err)
+	}
+	for _, dir := range dirs {
+		if _, err := syscall.InotifyAddWatch(fd, dir, watchMask); err != nil {
+			_ = syscall.Close(fd)
+			return nil, fmt.Errorf("watch %s: %w", dir, err)
+		}
+	}
+	// A non-blocking fd is registered with the runtime poller, so Close
+	// unblocks the pending Read.
+	file := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-ctx.Done()
+		_ = file.Close()
+	}()
+
+	events := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			if _, err := file.Read(buf); err != nil {
+				return
+			}
+			select {
+			case events <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return events, nil
+}
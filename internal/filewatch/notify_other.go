@@ -0,0 +1,13 @@
+//go:build !linux
+
+package filewatch
+
+import (
+	"context"
+	"errors"
+)
+
+// notify is not supported without inotify; the watcher polls instead.
+func notify(context.Context, []string) (<-chan struct{}, error) {
+	return nil, errors.New("file events are only supported on linux")
+}
@@ -0,0 +1,130 @@
+// Package filewatch detects changes to a set of files, using inotify where
+// available and polling otherwise.
+package filewatch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/containeroo/heartbeats/internal/logging"
+)
+
+const (
+	// DefaultDebounce is how long events must settle before files are compared.
+	DefaultDebounce = time.Second
+	// DefaultPollInterval is how often files are compared without inotify.
+	DefaultPollInterval = 10 * time.Second
+)
+
+// Options configures a Watcher.
+type Options struct {
+	Dirs         []string                 // Directories watched for events; usually the parents of the files.
+	Files        func() ([]string, error) // Lists the watched files; called on every check so added files are seen.
+	Debounce     time.Duration            // Quiet period after the last event (default 1s).
+	PollInterval time.Duration            // Interval of the polling fallback (default 10s).
+}
+
+// Watcher calls a function when the content of the watched files changes.
+// Events only trigger a comparison of file contents, so touching a file or
+// unrelated changes in a watched directory do not count as changes.
+type Watcher struct {
+	opts   Options
+	logger *slog.Logger
+	last   string // fingerprint of the last seen contents
+}
+
+// New returns a watcher for opts.
+func New(opts Options, logger *slog.Logger) *Watcher {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Watcher{opts: opts, logger: logger}
+}
+
+// Run watches until ctx is canceled and calls onChange after each change.
+// Without inotify, or when the directories cannot be watched, files are
+// polled instead.
+func (w *Watcher) Run(ctx context.Context, onChange func()) {
+	w.last, _ = w.fingerprint()
+
+	var poll <-chan time.Time
+	events, err := notify(ctx, w.opts.Dirs)
+	if err != nil {
+		w.logger.Warn("file events unavailable, polling for changes",
+			"event", logging.EventFileWatchPolling.String(),
+			"interval", w.opts.PollInterval,
+			"err", err,
+		)
+		ticker := time.NewTicker(w.opts.PollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	debounce := time.NewTimer(w.opts.Debounce)
+	debounce.Stop()
+	defer debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+			debounce.Reset(w.opts.Debounce)
+		case <-debounce.C:
+			w.check(onChange)
+		case <-poll:
+			w.check(onChange)
+		}
+	}
+}
+
+// check calls onChange when the fingerprint differs from the last one.
+func (w *Watcher) check(onChange func()) {
+	fp, err := w.fingerprint()
+	if err != nil {
+		// Usually a ConfigMap swap or editor save in progress; the next event or tick retries.
+		w.logger.Debug("read watched files", "err", err)
+		return
+	}
+	if fp == w.last {
+		return
+	}
+	w.last = fp
+	onChange()
+}
+
+// fingerprint hashes the names and contents of the watched files.
+func (w *Watcher) fingerprint() (string, error) {
+	files, err := w.opts.Files()
+	if err != nil {
+		return "", err
+	}
+	files = slices.Clone(files)
+	slices.Sort(files)
+	h := sha256.New()
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+		_, _ = io.WriteString(h, file+"\x00")
+		_, err = io.Copy(h, f)
+		_ = f.Close()
+		if err != nil {
+			return "", err
+		}
+		_, _ = io.WriteString(h, "\x00")
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,102 @@
+package filewatch
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// start runs a watcher on files in dir and returns a channel of changes.
+func start(t *testing.T, dirs []string, files ...string) <-chan struct{} {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	w := New(Options{
+		Dirs:         dirs,
+		Files:        func() ([]string, error) { return files, nil },
+		Debounce:     20 * time.Millisecond,
+		PollInterval: 20 * time.Millisecond,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	changes := make(chan struct{}, 10)
+	ready := make(chan struct{})
+	go func() {
+		close(ready)
+		w.Run(ctx, func() { changes <- struct{}{} })
+	}()
+	<-ready
+	time.Sleep(50 * time.Millisecond) // let Run take its first fingerprint
+	return changes
+}
+
+func requireChange(t *testing.T, changes <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a change")
+	}
+}
+
+func requireNoChange(t *testing.T, changes <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-changes:
+		t.Fatal("unexpected change")
+	case <-time.After(150 * time.Millisecond):
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	t.Parallel()
+
+	t.Run("write", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("a: 1\n"), 0o600))
+		changes := start(t, []string{dir}, path)
+
+		require.NoError(t, os.WriteFile(path, []byte("a: 2\n"), 0o600))
+		requireChange(t, changes)
+
+		now := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(path, now, now))
+		requireNoChange(t, changes)
+	})
+
+	t.Run("configmap symlink swap", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		// Layout of a mounted ConfigMap: config.yaml -> ..data/config.yaml, ..data -> ..v1.
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "..v1"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "..v1", "config.yaml"), []byte("a: 1\n"), 0o600))
+		require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+		require.NoError(t, os.Symlink(filepath.Join("..data", "config.yaml"), filepath.Join(dir, "config.yaml")))
+		changes := start(t, []string{dir}, filepath.Join(dir, "config.yaml"))
+
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "..v2"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "..v2", "config.yaml"), []byte("a: 2\n"), 0o600))
+		require.NoError(t, os.Symlink("..v2", filepath.Join(dir, "..data_tmp")))
+		require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, "..v1")))
+		requireChange(t, changes)
+	})
+
+	t.Run("polling fallback", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("a: 1\n"), 0o600))
+		changes := start(t, nil, path) // no directories: events are unavailable
+
+		require.NoError(t, os.WriteFile(path, []byte("a: 2\n"), 0o600))
+		requireChange(t, changes)
+		requireNoChange(t, changes)
+	})
+}
@@ -1,6 +1,7 @@
 package flag
 
 import (
+	"errors"
 	"net"
 	"strings"
 
@@ -10,15 +11,29 @@ import (
 	"github.com/containeroo/tinyflags"
 )
 
+// Client certificate policies for --tls-client-auth.
+const (
+	TLSClientAuthRequire  = "require"  // Reject connections without a valid client certificate.
+	TLSClientAuthOptional = "optional" // Verify client certificates only when presented.
+)
+
 // Options holds the application configuration.
 type Options struct {
 	Debug            bool              // Enable debug logging.
 	LogFormat        logging.LogFormat // Log output format.
 	ListenAddr       string            // Address to listen on.
 	RoutePrefix      string            // Route prefix for mounting.
-	ConfigPath       string            // Path to YAML config file.
+	ConfigPath       string            // Path to YAML config file, directory, or glob.
+	OverlayPath      string            // File persisting heartbeats and receivers changed through the API; empty = memory only.
+	QueuePath        string            // File persisting undelivered and failed notifications; empty = memory only.
+	TemplateDir      string            // Directory overriding built-in templates and providing partials; empty = built-ins only.
 	StrictEnv        bool              // Enforce env placeholders in config.
+	WatchConfig      bool              // Reload automatically when config files change.
 	SiteRoot         string            // Site root URL.
+	TLSCertFile      string            // PEM certificate served over TLS; empty = plain HTTP.
+	TLSKeyFile       string            // PEM private key for TLSCertFile.
+	TLSClientCAFile  string            // PEM CA bundle to verify client certificates; empty = no mTLS.
+	TLSClientAuth    string            // Client certificate policy: require or optional.
 	OverriddenValues map[string]any    // Overridden values from environment.
 }
 
@@ -42,12 +57,24 @@ func ParseFlags(args []string, version string) (Options, error) {
 		Placeholder("PATH").
 		Value()
 
-	tf.StringVar(&opts.ConfigPath, "config", "", "Path to YAML config file.").
+	tf.StringVar(&opts.ConfigPath, "config", "", "Path to YAML config file, directory, or glob.").
 		Short("c").
 		Placeholder("PATH").
 		Required().
 		Value()
 
+	tf.StringVar(&opts.OverlayPath, "overlay-file", "", "File persisting heartbeats and receivers changed through the API. Empty = keep changes in memory.").
+		Placeholder("PATH").
+		Value()
+
+	tf.StringVar(&opts.QueuePath, "queue-file", "", "File persisting undelivered and failed notifications. Empty = keep them in memory.").
+		Placeholder("PATH").
+		Value()
+
+	tf.StringVar(&opts.TemplateDir, "template-dir", "", "Directory whose *.tmpl files override built-in templates and define partials. Re-read on reload.").
+		Placeholder("DIR").
+		Value()
+
 	tf.StringVar(&opts.SiteRoot, "site-root", "http://localhost:8080", "Site root URL").
 		Finalize(func(input string) string {
 			return strings.TrimRight(input, "/")
@@ -63,6 +90,25 @@ func ParseFlags(args []string, version string) (Options, error) {
 	tf.BoolVar(&opts.StrictEnv, "strict-env", false, "Fail if config references unset env vars").
 		Value()
 
+	tf.BoolVar(&opts.WatchConfig, "watch-config", false, "Reload automatically when config files change").
+		Value()
+
+	tf.StringVar(&opts.TLSCertFile, "tls-cert-file", "", "TLS certificate file; enables HTTPS. Reloaded on change.").
+		Placeholder("PATH").
+		Value()
+
+	tf.StringVar(&opts.TLSKeyFile, "tls-key-file", "", "TLS private key file. Reloaded on change.").
+		Placeholder("PATH").
+		Value()
+
+	tf.StringVar(&opts.TLSClientCAFile, "tls-client-ca-file", "", "CA bundle to verify client certificates (mTLS)").
+		Placeholder("PATH").
+		Value()
+
+	tf.StringVar(&opts.TLSClientAuth, "tls-client-auth", TLSClientAuthRequire, "Client certificate policy with --tls-client-ca-file").
+		Choices(TLSClientAuthRequire, TLSClientAuthOptional).
+		Value()
+
 	logFormat := tf.String("log-format", "json", "Log format").
 		Choices(string(logging.LogFormatText), string(logging.LogFormatJSON)).
 		Short("l").
@@ -72,9 +118,55 @@ func ParseFlags(args []string, version string) (Options, error) {
 		return Options{}, err
 	}
 
+	if (opts.TLSCertFile == "") != (opts.TLSKeyFile == "") {
+		return Options{}, errors.New("--tls-cert-file and --tls-key-file must be set together")
+	}
+	if opts.TLSClientCAFile != "" && opts.TLSCertFile == "" {
+		return Options{}, errors.New("--tls-client-ca-file requires --tls-cert-file and --tls-key-file")
+	}
+
 	opts.ListenAddr = (*listenAddr).String()
 	opts.LogFormat = logging.LogFormat(*logFormat)
 	opts.OverriddenValues = tf.OverriddenValues()
 
 	return opts, nil
 }
+
+// ValidateOptions holds the options of the validate command.
+type ValidateOptions struct {
+	ConfigPath  string // Path to YAML config file, directory, or glob.
+	OverlayPath string // Overlay file merged over the config; empty = none.
+	TemplateDir string // Directory overriding built-in templates and providing partials.
+	StrictEnv   bool   // Enforce env placeholders in config.
+}
+
+// ParseValidateFlags parses the flags of `heartbeats validate`.
+func ParseValidateFlags(args []string, version string) (ValidateOptions, error) {
+	opts := ValidateOptions{}
+
+	tf := tinyflags.NewFlagSet("heartbeats validate", tinyflags.ContinueOnError)
+	tf.Version(version)
+	tf.EnvPrefix("HEARTBEATS_")
+
+	tf.StringVar(&opts.ConfigPath, "config", "", "Path to YAML config file, directory, or glob.").
+		Short("c").
+		Placeholder("PATH").
+		Required().
+		Value()
+
+	tf.StringVar(&opts.OverlayPath, "overlay-file", "", "Overlay file with heartbeats and receivers changed through the API.").
+		Placeholder("PATH").
+		Value()
+
+	tf.StringVar(&opts.TemplateDir, "template-dir", "", "Directory whose *.tmpl files override built-in templates and define partials.").
+		Placeholder("DIR").
+		Value()
+
+	tf.BoolVar(&opts.StrictEnv, "strict-env", false, "Fail if config references unset env vars").
+		Value()
+
+	if err := tf.Parse(args); err != nil {
+		return ValidateOptions{}, err
+	}
+	return opts, nil
+}
@@ -82,6 +82,8 @@ func TestParseFlags(t *testing.T) {
 			"-d",
 			"-l", "text",
 			"--config", "config.yaml",
+			"--queue-file", "/data/queue.json",
+			"--template-dir", "/etc/heartbeats/templates",
 		}
 		cfg, err := ParseFlags(args, "0.0.0")
 		assert.NoError(t, err)
@@ -89,6 +91,8 @@ func TestParseFlags(t *testing.T) {
 		assert.Equal(t, "/heartbeats", cfg.RoutePrefix)
 		assert.True(t, cfg.Debug)
 		assert.Equal(t, logging.LogFormat("text"), cfg.LogFormat)
+		assert.Equal(t, "/data/queue.json", cfg.QueuePath)
+		assert.Equal(t, "/etc/heartbeats/templates", cfg.TemplateDir)
 	})
 
 	t.Run("parsing error", func(t *testing.T) {
@@ -127,6 +131,40 @@ func TestParseFlags(t *testing.T) {
 		assert.EqualError(t, err, "invalid value for flag --log-format: \"xml\" must be one of: text, json")
 	})
 
+	t.Run("tls flags", func(t *testing.T) {
+		clearEnv(t)
+		args := []string{
+			"--config", "config.yaml",
+			"--tls-cert-file", "tls.crt",
+			"--tls-key-file", "tls.key",
+			"--tls-client-ca-file", "ca.crt",
+			"--tls-client-auth", "optional",
+		}
+		flags, err := ParseFlags(args, "")
+
+		require.NoError(t, err)
+		assert.Equal(t, "tls.crt", flags.TLSCertFile)
+		assert.Equal(t, "tls.key", flags.TLSKeyFile)
+		assert.Equal(t, "ca.crt", flags.TLSClientCAFile)
+		assert.Equal(t, TLSClientAuthOptional, flags.TLSClientAuth)
+	})
+
+	t.Run("tls key without cert", func(t *testing.T) {
+		clearEnv(t)
+		args := []string{"--config", "config.yaml", "--tls-key-file", "tls.key"}
+		_, err := ParseFlags(args, "")
+
+		assert.EqualError(t, err, "--tls-cert-file and --tls-key-file must be set together")
+	})
+
+	t.Run("tls client ca without cert", func(t *testing.T) {
+		clearEnv(t)
+		args := []string{"--config", "config.yaml", "--tls-client-ca-file", "ca.crt"}
+		_, err := ParseFlags(args, "")
+
+		assert.EqualError(t, err, "--tls-client-ca-file requires --tls-cert-file and --tls-key-file")
+	})
+
 	t.Run("test route prefix", func(t *testing.T) {
 		clearEnv(t)
 		args := []string{"--config", "config.yaml", "--route-prefix", "heartbeats"}
@@ -136,3 +174,20 @@ func TestParseFlags(t *testing.T) {
 		assert.Equal(t, "/heartbeats", flags.RoutePrefix)
 	})
 }
+
+func TestParseValidateFlags(t *testing.T) {
+	t.Run("flags", func(t *testing.T) {
+		clearEnv(t)
+		opts, err := ParseValidateFlags([]string{"--config", "conf.d/", "--overlay-file", "overlay.yaml", "--strict-env"}, "")
+
+		require.NoError(t, err)
+		assert.Equal(t, ValidateOptions{ConfigPath: "conf.d/", OverlayPath: "overlay.yaml", StrictEnv: true}, opts)
+	})
+
+	t.Run("config required", func(t *testing.T) {
+		clearEnv(t)
+		_, err := ParseValidateFlags(nil, "")
+
+		require.Error(t, err)
+	})
+}
@@ -7,10 +7,13 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/containeroo/heartbeats/internal/auth"
+	"github.com/containeroo/heartbeats/internal/config"
 	"github.com/containeroo/heartbeats/internal/heartbeat/service"
 	"github.com/containeroo/heartbeats/internal/history"
 	"github.com/containeroo/heartbeats/internal/logging"
 	"github.com/containeroo/heartbeats/internal/metrics"
+	"github.com/containeroo/heartbeats/internal/runner"
 	"github.com/containeroo/heartbeats/internal/ws"
 )
 
@@ -18,26 +21,47 @@ import (
 type ServiceProvider interface {
 	HeartbeatSummaries() []service.HeartbeatSummary
 	ReceiverSummaries() []service.ReceiverSummary
-	Update(id string, payload string, now time.Time) error
+	Provision(id string, creds service.PingCredentials, payload string, now time.Time) error
+	VerifyPing(id string, creds service.PingCredentials, payload string, now time.Time) error
+	Update(id, contentType, payload string, now time.Time) error
+	Payloads(id string) ([]runner.PayloadRecord, error)
 	StatusAll() []service.Status
 	StatusByID(id string) (service.Status, error)
 }
 
+// OverlayStore applies heartbeat and receiver changes made through the API.
+type OverlayStore interface {
+	CreateHeartbeat(id string, hb config.HeartbeatConfig) error
+	PutHeartbeat(id string, hb config.HeartbeatConfig) (bool, error)
+	DeleteHeartbeat(id string) error
+	Receiver(name string) (config.ReceiverConfig, bool)
+	CreateReceiver(name string, rcv config.ReceiverConfig) error
+	PutReceiver(name string, rcv config.ReceiverConfig) (bool, error)
+	DeleteReceiver(name string) error
+}
+
 type websocketHub interface {
 	Handle(http.ResponseWriter, *http.Request)
 }
 
 // API bundles shared handler dependencies and runtime configuration.
 type API struct {
-	Version  string            // Build version string.
-	Commit   string            // Build commit string.
-	SiteURL  string            // Site root URL.
-	Logger   *slog.Logger      // Logger for access/system logs.
-	service  ServiceProvider   // Domain service for heartbeat state.
-	history  history.Recorder  // In-memory history recorder.
-	metrics  *metrics.Registry // Prometheus metrics registry.
-	wsHub    websocketHub      // websocket hub.
-	reloadFn func() error      // reload function.
+	Version        string                  // Build version string.
+	Commit         string                  // Build commit string.
+	SiteURL        string                  // Site root URL.
+	Logger         *slog.Logger            // Logger for access/system logs.
+	service        ServiceProvider         // Domain service for heartbeat state.
+	history        history.Recorder        // In-memory history recorder.
+	metrics        *metrics.Registry       // Prometheus metrics registry.
+	wsHub          websocketHub            // websocket hub.
+	reloadFn       ReloadFunc              // reload function.
+	rollbackFn     func() error            // restores the previous config generation.
+	generationFn   func() (uint64, string) // active config generation and hash.
+	authn          *auth.Authenticator     // dashboard and management API authentication.
+	overlay        OverlayStore            // runtime heartbeat and receiver changes.
+	queue          NotificationQueue       // outbound notification queue.
+	receiverTestFn ReceiverTestFunc        // sends test notifications to receivers.
+	renderFn       TemplateRenderFunc      // renders template previews.
 }
 
 // NewAPI builds an API container with shared handler dependencies.
@@ -79,11 +103,45 @@ func (a *API) SetHub(hub *ws.Hub) {
 	a.wsHub = hub
 }
 
+// ReloadFunc reloads the config and returns what changed; with dryRun set,
+// nothing is applied.
+type ReloadFunc func(dryRun bool) (config.Diff, error)
+
 // SetReloadFn attaches a reload function to the API.
-func (a *API) SetReloadFn(fn func() error) {
+func (a *API) SetReloadFn(fn ReloadFunc) {
 	a.reloadFn = fn
 }
 
+// SetRollbackFn attaches the function restoring the previous config generation.
+func (a *API) SetRollbackFn(fn func() error) {
+	a.rollbackFn = fn
+}
+
+// SetGenerationFn attaches a function reporting the active config generation and hash.
+func (a *API) SetGenerationFn(fn func() (uint64, string)) {
+	a.generationFn = fn
+}
+
+// SetReceiverTestFn attaches the function sending test notifications to receivers.
+func (a *API) SetReceiverTestFn(fn ReceiverTestFunc) {
+	a.receiverTestFn = fn
+}
+
+// SetAuthenticator attaches the authenticator guarding the dashboard and management API.
+func (a *API) SetAuthenticator(authn *auth.Authenticator) {
+	a.authn = authn
+}
+
+// SetOverlay attaches the store for config changes made through the API.
+func (a *API) SetOverlay(store OverlayStore) {
+	a.overlay = store
+}
+
+// Authenticator returns the configured authenticator, if any.
+func (a *API) Authenticator() *auth.Authenticator {
+	return a.authn
+}
+
 // respondJSON writes a JSON response and logs failures.
 func (a *API) respondJSON(w http.ResponseWriter, status int, v any) {
 	if err := encode(w, status, v); err != nil {
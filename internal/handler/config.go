@@ -4,18 +4,24 @@ import "net/http"
 
 // configResponse describes runtime settings exposed to the SPA.
 type configResponse struct {
-	Version string `json:"version"` // Version is the build version string.
-	Commit  string `json:"commit"`  // Commit is the build commit SHA.
-	SiteURL string `json:"siteUrl"` // SiteURL is the site root URL.
+	Version    string `json:"version"`              // Version is the build version string.
+	Commit     string `json:"commit"`               // Commit is the build commit SHA.
+	SiteURL    string `json:"siteUrl"`              // SiteURL is the site root URL.
+	Generation uint64 `json:"generation,omitempty"` // Generation counts applied config changes, starting at 1.
+	ConfigHash string `json:"configHash,omitempty"` // ConfigHash is the SHA-256 of the active config.
 }
 
 // Config returns runtime configuration for the SPA.
 func (a *API) Config() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		a.respondJSON(w, http.StatusOK, configResponse{
+		resp := configResponse{
 			Version: a.Version,
 			Commit:  a.Commit,
 			SiteURL: a.SiteURL,
-		})
+		}
+		if a.generationFn != nil {
+			resp.Generation, resp.ConfigHash = a.generationFn()
+		}
+		a.respondJSON(w, http.StatusOK, resp)
 	}
 }
@@ -33,5 +33,18 @@ func TestConfig(t *testing.T) {
 		assert.Equal(t, "v1", payload.Version)
 		assert.Equal(t, "c1", payload.Commit)
 		assert.Equal(t, "http://example.com", payload.SiteURL)
+		assert.Zero(t, payload.Generation)
+	})
+
+	t.Run("Reports the config generation", func(t *testing.T) {
+		t.Parallel()
+		api := &API{Version: "v1"}
+		api.SetGenerationFn(func() (uint64, string) { return 3, "abc123" })
+
+		rec := httptest.NewRecorder()
+		api.Config().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"version":"v1","commit":"","siteUrl":"","generation":3,"configHash":"abc123"}`, rec.Body.String())
 	})
 }
@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/containeroo/heartbeats/internal/heartbeat/service"
 )
 
 // Heartbeat receives heartbeat pings for a specific heartbeat id.
@@ -19,10 +22,78 @@ func (a *API) Heartbeat() http.HandlerFunc {
 		body, _ := io.ReadAll(io.LimitReader(r.Body, 1<<20))
 		_ = r.Body.Close()
 
-		if err := a.service.Update(heartbeatID, string(body), now); err != nil {
+		creds := service.PingCredentials{
+			Token:          pingToken(r),
+			Signature:      r.Header.Get("X-Heartbeat-Signature"),
+			ClientSubjects: clientSubjects(r),
+		}
+		if err := a.service.Provision(heartbeatID, creds, string(body), now); err != nil {
+			if errors.Is(err, service.ErrPingUnauthorized) {
+				a.respondJSON(w, http.StatusUnauthorized, errorResponse{Error: err.Error()})
+				return
+			}
+			a.respondOverlayError(w, err)
+			return
+		}
+		if err := a.service.VerifyPing(heartbeatID, creds, string(body), now); err != nil {
+			if errors.Is(err, service.ErrPingUnauthorized) {
+				a.respondJSON(w, http.StatusUnauthorized, errorResponse{Error: err.Error()})
+				return
+			}
+			a.respondJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+			return
+		}
+
+		if err := a.service.Update(heartbeatID, r.Header.Get("Content-Type"), string(body), now); err != nil {
+			if errors.Is(err, service.ErrPayloadRejected) {
+				a.respondJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: err.Error()})
+				return
+			}
 			a.respondJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
 			return
 		}
 		a.respondJSON(w, http.StatusOK, statusResponse{Status: "ok"})
 	}
 }
+
+// pingToken returns the ping token from the path, header, or query, in that order.
+func pingToken(r *http.Request) string {
+	if token := r.PathValue("token"); token != "" {
+		return token
+	}
+	if token := r.Header.Get("X-Heartbeat-Token"); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
+
+// clientSubjects returns the names of the verified TLS client certificate.
+func clientSubjects(r *http.Request) []string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	subjects := []string{cert.Subject.String()}
+	if cert.Subject.CommonName != "" {
+		subjects = append(subjects, cert.Subject.CommonName)
+	}
+	return append(subjects, cert.DNSNames...)
+}
+
+// HeartbeatPayloads returns the recent payloads of a heartbeat, newest first.
+func (a *API) HeartbeatPayloads() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		heartbeatID := r.PathValue("id")
+		if heartbeatID == "" {
+			a.respondJSON(w, http.StatusBadRequest, errorResponse{Error: "missing heartbeat id"})
+			return
+		}
+
+		payloads, err := a.service.Payloads(heartbeatID)
+		if err != nil {
+			a.respondJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+			return
+		}
+		a.respondJSON(w, http.StatusOK, payloads)
+	}
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/containeroo/heartbeats/internal/config"
+)
+
+// CreateHeartbeat adds a heartbeat at runtime. The body is a heartbeat
+// definition in JSON or YAML, using the config file field names.
+func (a *API) CreateHeartbeat() http.HandlerFunc {
+	return editConfig(a, "id", config.ParseHeartbeat, func(id string, hb config.HeartbeatConfig) (int, string, error) {
+		return createResult(a.overlay.CreateHeartbeat(id, hb))
+	})
+}
+
+// PutHeartbeat creates or replaces a heartbeat at runtime.
+func (a *API) PutHeartbeat() http.HandlerFunc {
+	return editConfig(a, "id", config.ParseHeartbeat, func(id string, hb config.HeartbeatConfig) (int, string, error) {
+		return upsertResult(a.overlay.PutHeartbeat(id, hb))
+	})
+}
+
+// DeleteHeartbeat removes a heartbeat at runtime.
+func (a *API) DeleteHeartbeat() http.HandlerFunc {
+	return a.deleteConfig("id", func(id string) error {
+		return a.overlay.DeleteHeartbeat(id)
+	})
+}
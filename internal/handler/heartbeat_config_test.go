@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/overlay"
+)
+
+func TestHeartbeatConfigHandlers(t *testing.T) {
+	t.Parallel()
+
+	newAPI := func(t *testing.T) (*API, *overlay.Store) {
+		t.Helper()
+		store, err := overlay.NewStore("")
+		require.NoError(t, err)
+		store.SetConfig(&config.Config{
+			Receivers: map[string]config.ReceiverConfig{
+				"ops": {Webhooks: []config.WebhookConfig{{URL: "https://example.com"}}},
+			},
+			Heartbeats: map[string]config.HeartbeatConfig{
+				"api": {Interval: time.Minute, LateAfter: time.Minute, Receivers: []string{"ops"}},
+			},
+		})
+		api := NewAPI("test", "test", "", slog.New(slog.NewTextHandler(&strings.Builder{}, nil)))
+		api.SetOverlay(store)
+		return api, store
+	}
+
+	serve := func(api *API, method, id, body string) *httptest.ResponseRecorder {
+		mux := http.NewServeMux()
+		mux.Handle("POST /heartbeats/{id}", api.CreateHeartbeat())
+		mux.Handle("PUT /heartbeats/{id}", api.PutHeartbeat())
+		mux.Handle("DELETE /heartbeats/{id}", api.DeleteHeartbeat())
+		req := httptest.NewRequest(method, "/heartbeats/"+id, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	valid := `{"interval":"1h","late_after":"5m","receivers":["ops"]}`
+
+	t.Run("create", func(t *testing.T) {
+		t.Parallel()
+		api, store := newAPI(t)
+
+		rec := serve(api, http.MethodPost, "backup", valid)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		assert.JSONEq(t, `{"status":"created"}`, rec.Body.String())
+		assert.Equal(t, time.Hour, store.Overlay().Heartbeats["backup"].Interval)
+
+		rec = serve(api, http.MethodPost, "api", valid)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("put", func(t *testing.T) {
+		t.Parallel()
+		api, _ := newAPI(t)
+
+		rec := serve(api, http.MethodPut, "api", valid)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"updated"}`, rec.Body.String())
+
+		rec = serve(api, http.MethodPut, "backup", "interval: 1h\nlate_after: 5m\nreceivers: [ops]\n")
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		api, _ := newAPI(t)
+
+		rec := serve(api, http.MethodPut, "backup", `{"interval":"1h","late_after":"5m","receivers":["pager"]}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `references unknown receiver`)
+
+		rec = serve(api, http.MethodPut, "backup", `{"intervall":"1h"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		t.Parallel()
+		api, _ := newAPI(t)
+
+		rec := serve(api, http.MethodDelete, "ghost", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		require.Equal(t, http.StatusCreated, serve(api, http.MethodPost, "backup", valid).Code)
+		rec = serve(api, http.MethodDelete, "api", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"deleted"}`, rec.Body.String())
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("test", "test", "", slog.New(slog.NewTextHandler(&strings.Builder{}, nil)))
+		rec := serve(api, http.MethodPut, "api", valid)
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+	})
+}
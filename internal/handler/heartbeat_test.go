@@ -2,6 +2,7 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -10,13 +11,28 @@ import (
 	"time"
 
 	"github.com/containeroo/heartbeats/internal/heartbeat/service"
+	"github.com/containeroo/heartbeats/internal/overlay"
+	"github.com/containeroo/heartbeats/internal/runner"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 type fakeService struct {
-	updateErr error
-	updated   []string
+	provisionErr error
+	updateErr    error
+	verifyErr    error
+	updated      []string
+	creds        []service.PingCredentials
+	payloads     map[string][]runner.PayloadRecord
+}
+
+func (f *fakeService) Provision(id string, creds service.PingCredentials, payload string, now time.Time) error {
+	return f.provisionErr
+}
+
+func (f *fakeService) VerifyPing(id string, creds service.PingCredentials, payload string, now time.Time) error {
+	f.creds = append(f.creds, creds)
+	return f.verifyErr
 }
 
 func (f *fakeService) HeartbeatSummaries() []service.HeartbeatSummary { return nil }
@@ -24,7 +40,15 @@ func (f *fakeService) ReceiverSummaries() []service.ReceiverSummary   { return n
 func (f *fakeService) StatusAll() []service.Status                    { return nil }
 func (f *fakeService) StatusByID(id string) (service.Status, error)   { return service.Status{}, nil }
 
-func (f *fakeService) Update(id string, payload string, now time.Time) error {
+func (f *fakeService) Payloads(id string) ([]runner.PayloadRecord, error) {
+	payloads, ok := f.payloads[id]
+	if !ok {
+		return nil, fmt.Errorf("heartbeat %q not found", id)
+	}
+	return payloads, nil
+}
+
+func (f *fakeService) Update(id, contentType, payload string, now time.Time) error {
 	if f.updateErr != nil {
 		return f.updateErr
 	}
@@ -76,6 +100,71 @@ func TestHeartbeatHandler(t *testing.T) {
 		require.Equal(t, http.StatusNotFound, rec.Code)
 	})
 
+	t.Run("rejected payload maps to 422", func(t *testing.T) {
+		t.Parallel()
+
+		svc := &fakeService{updateErr: fmt.Errorf("%w: payload is not valid JSON", service.ErrPayloadRejected)}
+		api := newHeartbeatAPI(svc)
+
+		rec := bump(t, api, "api")
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), "not valid JSON")
+	})
+
+	t.Run("unauthorized ping maps to 401 and skips the update", func(t *testing.T) {
+		t.Parallel()
+
+		svc := &fakeService{verifyErr: fmt.Errorf("%w: invalid token", service.ErrPingUnauthorized)}
+		api := newHeartbeatAPI(svc)
+
+		rec := bump(t, api, "api")
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, svc.updated)
+	})
+
+	t.Run("auto-provision limit maps to 403", func(t *testing.T) {
+		t.Parallel()
+
+		svc := &fakeService{provisionErr: fmt.Errorf("provision heartbeat %q: %w", "cron-9", overlay.ErrLimit)}
+		api := newHeartbeatAPI(svc)
+
+		rec := bump(t, api, "cron-9")
+		require.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "auto-provision limit reached")
+		assert.Empty(t, svc.updated)
+	})
+
+	t.Run("token and signature are passed to verification", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name  string
+			setup func(r *http.Request)
+		}{
+			{"path", func(r *http.Request) { r.SetPathValue("token", "s3cret") }},
+			{"header", func(r *http.Request) { r.Header.Set("X-Heartbeat-Token", "s3cret") }},
+			{"query", func(r *http.Request) { r.URL.RawQuery = "token=s3cret" }},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				svc := &fakeService{}
+				api := newHeartbeatAPI(svc)
+				req := httptest.NewRequest("POST", "/api/heartbeat/api", strings.NewReader("payload"))
+				req.SetPathValue("id", "api")
+				req.Header.Set("X-Heartbeat-Signature", "sha256=abc")
+				tc.setup(req)
+				rec := httptest.NewRecorder()
+				api.Heartbeat().ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Len(t, svc.creds, 1)
+				assert.Equal(t, service.PingCredentials{Token: "s3cret", Signature: "sha256=abc"}, svc.creds[0])
+			})
+		}
+	})
+
 	t.Run("missing id is rejected before the service", func(t *testing.T) {
 		t.Parallel()
 
@@ -87,3 +176,35 @@ func TestHeartbeatHandler(t *testing.T) {
 		assert.Empty(t, svc.updated)
 	})
 }
+
+func TestHeartbeatPayloadsHandler(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeService{payloads: map[string][]runner.PayloadRecord{
+		"api": {{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ContentType: "application/json", Size: 2, Body: "{}"}},
+	}}
+	api := newHeartbeatAPI(svc)
+
+	t.Run("returns stored payloads", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest("GET", "/api/heartbeat/api/payloads", nil)
+		req.SetPathValue("id", "api")
+		rec := httptest.NewRecorder()
+		api.HeartbeatPayloads().ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[{"timestamp":"2024-01-01T00:00:00Z","contentType":"application/json","size":2,"body":"{}"}]`, rec.Body.String())
+	})
+
+	t.Run("unknown heartbeat maps to 404", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest("GET", "/api/heartbeat/ghost/payloads", nil)
+		req.SetPathValue("id", "ghost")
+		rec := httptest.NewRecorder()
+		api.HeartbeatPayloads().ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/containeroo/heartbeats/internal/outbox"
+)
+
+// NotificationQueue exposes notifications that exhausted their retries.
+type NotificationQueue interface {
+	Failed() []outbox.Entry
+	Replay(id string) error
+}
+
+// SetNotificationQueue attaches the outbound notification queue.
+func (a *API) SetNotificationQueue(q NotificationQueue) {
+	a.queue = q
+}
+
+// FailedNotifications returns the dead-lettered notifications, most recent first.
+func (a *API) FailedNotifications() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.queue == nil {
+			a.respondJSON(w, http.StatusNotImplemented, errorResponse{Error: "notification queue not configured"})
+			return
+		}
+		a.respondJSON(w, http.StatusOK, a.queue.Failed())
+	}
+}
+
+// ReplayNotification queues a dead-lettered notification for delivery again.
+func (a *API) ReplayNotification() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.queue == nil {
+			a.respondJSON(w, http.StatusNotImplemented, errorResponse{Error: "notification queue not configured"})
+			return
+		}
+		err := a.queue.Replay(r.PathValue("id"))
+		if errors.Is(err, outbox.ErrNotFound) {
+			a.respondJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			a.respondJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+			return
+		}
+		a.respondJSON(w, http.StatusAccepted, statusResponse{Status: "queued"})
+	}
+}
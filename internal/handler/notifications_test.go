@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containeroo/heartbeats/internal/notify"
+	"github.com/containeroo/heartbeats/internal/outbox"
+)
+
+type fakeQueue struct {
+	failed   []outbox.Entry
+	replayed []string
+}
+
+func (q *fakeQueue) Failed() []outbox.Entry { return q.failed }
+
+func (q *fakeQueue) Replay(id string) error {
+	for _, e := range q.failed {
+		if e.ID == id {
+			q.replayed = append(q.replayed, id)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: notification %q", outbox.ErrNotFound, id)
+}
+
+func TestNotificationHandlers(t *testing.T) {
+	t.Parallel()
+
+	newAPI := func(q NotificationQueue) *API {
+		api := NewAPI("test", "test", "", slog.New(slog.NewTextHandler(&strings.Builder{}, nil)))
+		if q != nil {
+			api.SetNotificationQueue(q)
+		}
+		return api
+	}
+	entry := outbox.Entry{
+		ID:         "1-1.0",
+		Receiver:   "ops",
+		TargetType: "webhook",
+		Event:      &notify.Event{IDValue: "1-1", Heartbeat: "api"},
+		Attempts:   6,
+		LastError:  "connection refused",
+	}
+
+	t.Run("lists failed notifications", func(t *testing.T) {
+		t.Parallel()
+		api := newAPI(&fakeQueue{failed: []outbox.Entry{entry}})
+
+		rec := httptest.NewRecorder()
+		api.FailedNotifications().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/notifications/failed", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got []outbox.Entry
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		require.Len(t, got, 1)
+		assert.Equal(t, "connection refused", got[0].LastError)
+		assert.Equal(t, "api", got[0].Event.Heartbeat)
+	})
+
+	t.Run("replays a failed notification", func(t *testing.T) {
+		t.Parallel()
+		q := &fakeQueue{failed: []outbox.Entry{entry}}
+		api := newAPI(q)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/notifications/failed/1-1.0/replay", nil)
+		req.SetPathValue("id", "1-1.0")
+		rec := httptest.NewRecorder()
+		api.ReplayNotification().ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Equal(t, []string{"1-1.0"}, q.replayed)
+	})
+
+	t.Run("replay of unknown notification", func(t *testing.T) {
+		t.Parallel()
+		api := newAPI(&fakeQueue{})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/notifications/failed/missing/replay", nil)
+		req.SetPathValue("id", "missing")
+		rec := httptest.NewRecorder()
+		api.ReplayNotification().ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("queue not configured", func(t *testing.T) {
+		t.Parallel()
+		api := newAPI(nil)
+
+		rec := httptest.NewRecorder()
+		api.FailedNotifications().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/notifications/failed", nil))
+
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+	})
+}
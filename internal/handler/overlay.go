@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/containeroo/heartbeats/internal/overlay"
+)
+
+// maxConfigBodyBytes bounds heartbeat and receiver definitions sent to the API.
+const maxConfigBodyBytes = 1 << 20
+
+// editConfig parses a definition from the body and passes it to apply
+// together with the {key} path value. apply returns the status code and
+// the status string of the response.
+func editConfig[T any](
+	a *API,
+	key string,
+	parse func([]byte) (T, error),
+	apply func(name string, v T) (int, string, error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.overlay == nil {
+			a.respondJSON(w, http.StatusNotImplemented, errorResponse{Error: "config changes not configured"})
+			return
+		}
+		name := r.PathValue(key)
+		if name == "" {
+			a.respondJSON(w, http.StatusBadRequest, errorResponse{Error: "missing " + key})
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxConfigBodyBytes))
+		if err != nil {
+			a.respondJSON(w, http.StatusBadRequest, errorResponse{Error: "read body: " + err.Error()})
+			return
+		}
+		v, err := parse(body)
+		if err != nil {
+			a.respondJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+		status, result, err := apply(name, v)
+		if err != nil {
+			a.respondOverlayError(w, err)
+			return
+		}
+		a.respondJSON(w, status, statusResponse{Status: result})
+	}
+}
+
+// deleteConfig removes the entry named by the {key} path value.
+func (a *API) deleteConfig(key string, remove func(name string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.overlay == nil {
+			a.respondJSON(w, http.StatusNotImplemented, errorResponse{Error: "config changes not configured"})
+			return
+		}
+		if err := remove(r.PathValue(key)); err != nil {
+			a.respondOverlayError(w, err)
+			return
+		}
+		a.respondJSON(w, http.StatusOK, statusResponse{Status: "deleted"})
+	}
+}
+
+// upsertResult maps the created flag of a put to the response.
+func upsertResult(created bool, err error) (int, string, error) {
+	if err != nil {
+		return 0, "", err
+	}
+	if created {
+		return http.StatusCreated, "created", nil
+	}
+	return http.StatusOK, "updated", nil
+}
+
+// createResult maps the result of a create to the response.
+func createResult(err error) (int, string, error) {
+	if err != nil {
+		return 0, "", err
+	}
+	return http.StatusCreated, "created", nil
+}
+
+// respondOverlayError maps overlay errors to HTTP status codes.
+func (a *API) respondOverlayError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, overlay.ErrInvalid):
+		status = http.StatusBadRequest
+	case errors.Is(err, overlay.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, overlay.ErrExists):
+		status = http.StatusConflict
+	case errors.Is(err, overlay.ErrLimit):
+		status = http.StatusForbidden
+	}
+	a.respondJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/notify"
+)
+
+// ReceiverConfig returns the definition of a receiver with secrets redacted.
+func (a *API) ReceiverConfig() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.overlay == nil {
+			a.respondJSON(w, http.StatusNotImplemented, errorResponse{Error: "config changes not configured"})
+			return
+		}
+		name := r.PathValue("name")
+		rcv, ok := a.overlay.Receiver(name)
+		if !ok {
+			a.respondJSON(w, http.StatusNotFound, errorResponse{Error: "receiver " + name + " not found"})
+			return
+		}
+		doc, err := config.Document(rcv.Redacted())
+		if err != nil {
+			a.respondJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+			return
+		}
+		a.respondJSON(w, http.StatusOK, doc)
+	}
+}
+
+// CreateReceiver adds a receiver at runtime. The body is a receiver
+// definition in JSON or YAML, using the config file field names.
+func (a *API) CreateReceiver() http.HandlerFunc {
+	return editConfig(a, "name", config.ParseReceiver, func(name string, rcv config.ReceiverConfig) (int, string, error) {
+		return createResult(a.overlay.CreateReceiver(name, rcv))
+	})
+}
+
+// PutReceiver creates or replaces a receiver at runtime. Secrets sent as
+// config.RedactedSecret keep their current values.
+func (a *API) PutReceiver() http.HandlerFunc {
+	return editConfig(a, "name", config.ParseReceiver, func(name string, rcv config.ReceiverConfig) (int, string, error) {
+		return upsertResult(a.overlay.PutReceiver(name, rcv))
+	})
+}
+
+// DeleteReceiver removes a receiver at runtime.
+func (a *API) DeleteReceiver() http.HandlerFunc {
+	return a.deleteConfig("name", func(name string) error {
+		return a.overlay.DeleteReceiver(name)
+	})
+}
+
+// ReceiverTestFunc sends a test notification to every target of a receiver.
+type ReceiverTestFunc func(ctx context.Context, name string) ([]notify.TargetResult, error)
+
+// receiverTestResponse reports the outcome of a test notification.
+type receiverTestResponse struct {
+	Receiver string                `json:"receiver"` // Receiver name.
+	OK       bool                  `json:"ok"`       // Whether every target accepted the notification.
+	Targets  []notify.TargetResult `json:"targets"`  // Outcome per target.
+}
+
+// TestReceiver sends a synthetic notification through the targets of a
+// receiver and reports the outcome of each delivery.
+func (a *API) TestReceiver() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.receiverTestFn == nil {
+			a.respondJSON(w, http.StatusNotImplemented, errorResponse{Error: "receiver tests not configured"})
+			return
+		}
+		name := r.PathValue("name")
+		results, err := a.receiverTestFn(r.Context(), name)
+		if errors.Is(err, notify.ErrUnknownReceiver) {
+			a.respondJSON(w, http.StatusNotFound, errorResponse{Error: "receiver " + name + " not found"})
+			return
+		}
+		if err != nil {
+			a.respondJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+			return
+		}
+		resp := receiverTestResponse{Receiver: name, OK: true, Targets: results}
+		for _, result := range results {
+			resp.OK = resp.OK && result.OK
+		}
+		a.respondJSON(w, http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/notify"
+	"github.com/containeroo/heartbeats/internal/overlay"
+)
+
+func TestReceiverConfigHandlers(t *testing.T) {
+	t.Parallel()
+
+	newAPI := func(t *testing.T) *API {
+		t.Helper()
+		store, err := overlay.NewStore("")
+		require.NoError(t, err)
+		cfg := &config.Config{
+			Receivers: map[string]config.ReceiverConfig{
+				"ops": {Webhooks: []config.WebhookConfig{{
+					URL:     "https://example.com",
+					Headers: map[string]string{"Authorization": "Bearer s3cret"},
+				}}},
+			},
+			Heartbeats: map[string]config.HeartbeatConfig{
+				"api": {Interval: time.Minute, LateAfter: time.Minute, Receivers: []string{"ops"}},
+			},
+		}
+		store.SetConfig(cfg)
+		// Stand-in for the reload, which records the merged config.
+		store.SetApplyFn(func() error {
+			next := *cfg
+			next.Receivers = make(map[string]config.ReceiverConfig)
+			for name, rcv := range cfg.Receivers {
+				next.Receivers[name] = rcv
+			}
+			store.Overlay().Apply(&next)
+			store.SetConfig(&next)
+			return nil
+		})
+		api := NewAPI("test", "test", "", slog.New(slog.NewTextHandler(&strings.Builder{}, nil)))
+		api.SetOverlay(store)
+		return api
+	}
+
+	serve := func(api *API, method, name, body string) *httptest.ResponseRecorder {
+		mux := http.NewServeMux()
+		mux.Handle("GET /receivers/{name}", api.ReceiverConfig())
+		mux.Handle("POST /receivers/{name}", api.CreateReceiver())
+		mux.Handle("PUT /receivers/{name}", api.PutReceiver())
+		mux.Handle("DELETE /receivers/{name}", api.DeleteReceiver())
+		req := httptest.NewRequest(method, "/receivers/"+name, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("secrets are write-only", func(t *testing.T) {
+		t.Parallel()
+		api := newAPI(t)
+
+		rec := serve(api, http.MethodGet, "ops", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"webhooks":[{"url":"https://example.com","headers":{"Authorization":"<redacted>"}}]}`, rec.Body.String())
+
+		// Sending the redacted document back keeps the stored secret.
+		rec = serve(api, http.MethodPut, "ops", `{"webhooks":[{"url":"https://example.com","method":"PUT","headers":{"Authorization":"<redacted>"}}]}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"updated"}`, rec.Body.String())
+
+		rcv, ok := api.overlay.Receiver("ops")
+		require.True(t, ok)
+		assert.Equal(t, "PUT", rcv.Webhooks[0].Method)
+		assert.Equal(t, "Bearer s3cret", rcv.Webhooks[0].Headers["Authorization"])
+
+		// A redacted secret never moves to another destination.
+		rec = serve(api, http.MethodPut, "ops", `{"webhooks":[{"url":"https://example.org","headers":{"Authorization":"<redacted>"}}]}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.NotContains(t, serve(api, http.MethodGet, "ops", "").Body.String(), "s3cret")
+	})
+
+	t.Run("create and delete", func(t *testing.T) {
+		t.Parallel()
+		api := newAPI(t)
+
+		body := `{"emails":[{"host":"smtp","port":587,"from":"hb@example.com","to":["ops@example.com"],"pass":"hunter2"}],"retry":{"count":3,"delay":"5s"}}`
+		rec := serve(api, http.MethodPost, "mail", body)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, http.StatusConflict, serve(api, http.MethodPost, "mail", body).Code)
+
+		rec = serve(api, http.MethodDelete, "ops", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "ops is referenced by a heartbeat")
+		assert.Contains(t, rec.Body.String(), `unknown receiver \"ops\"`)
+
+		assert.Equal(t, http.StatusOK, serve(api, http.MethodDelete, "mail", "").Code)
+		assert.Equal(t, http.StatusNotFound, serve(api, http.MethodGet, "mail", "").Code)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		api := newAPI(t)
+
+		rec := serve(api, http.MethodPut, "mail", `{"emails":[{"host":"smtp"}]}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "host/from/to are required")
+	})
+}
+
+func TestTestReceiver(t *testing.T) {
+	t.Parallel()
+
+	newAPI := func() *API {
+		api := NewAPI("test", "test", "", slog.New(slog.NewTextHandler(&strings.Builder{}, nil)))
+		api.SetReceiverTestFn(func(_ context.Context, name string) ([]notify.TargetResult, error) {
+			if name != "ops" {
+				return nil, notify.ErrUnknownReceiver
+			}
+			return []notify.TargetResult{
+				{Type: "webhook", Destination: "https://example.com", OK: true, StatusCode: http.StatusOK},
+				{Type: "email", Destination: "ops@example.com", Error: "dial tcp: connection refused"},
+			}, nil
+		})
+		return api
+	}
+	send := func(api *API, name string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/receivers/"+name+"/test", nil)
+		req.SetPathValue("name", name)
+		rec := httptest.NewRecorder()
+		api.TestReceiver().ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("reports every target", func(t *testing.T) {
+		t.Parallel()
+		rec := send(newAPI(), "ops")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp receiverTestResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, "ops", resp.Receiver)
+		assert.False(t, resp.OK)
+		require.Len(t, resp.Targets, 2)
+		assert.True(t, resp.Targets[0].OK)
+		assert.Equal(t, "dial tcp: connection refused", resp.Targets[1].Error)
+	})
+
+	t.Run("unknown receiver", func(t *testing.T) {
+		t.Parallel()
+		rec := send(newAPI(), "missing")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("build failure", func(t *testing.T) {
+		t.Parallel()
+		api := NewAPI("test", "test", "", slog.New(slog.NewTextHandler(&strings.Builder{}, nil)))
+		api.SetReceiverTestFn(func(context.Context, string) ([]notify.TargetResult, error) {
+			return nil, errors.New("parse template")
+		})
+		rec := send(api, "ops")
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
@@ -1,18 +1,63 @@
 package handler
 
-import "net/http"
+import (
+	"errors"
+	"net/http"
+	"strconv"
 
-// ReloadHandler triggers a config reload.
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/heartbeat/reconcile"
+)
+
+// reloadResponse reports what a reload changed or, for dry runs, would change.
+type reloadResponse struct {
+	Status string      `json:"status"`
+	DryRun bool        `json:"dry_run"`
+	Diff   config.Diff `json:"diff"`
+}
+
+// ReloadHandler triggers a config reload. With ?dry_run=true the config is
+// only loaded and compared with the running one.
 func (a *API) ReloadHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if a.reloadFn == nil {
 			a.respondJSON(w, http.StatusNotImplemented, errorResponse{Error: "reload not configured"})
 			return
 		}
-		if err := a.reloadFn(); err != nil {
+		dryRun := false
+		if value := r.URL.Query().Get("dry_run"); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				a.respondJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid dry_run value"})
+				return
+			}
+			dryRun = parsed
+		}
+		diff, err := a.reloadFn(dryRun)
+		if err != nil {
 			a.respondJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
 			return
 		}
+		a.respondJSON(w, http.StatusOK, reloadResponse{Status: "ok", DryRun: dryRun, Diff: diff})
+	})
+}
+
+// RollbackHandler re-applies the config generation replaced by the last
+// reload that changed the config.
+func (a *API) RollbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.rollbackFn == nil {
+			a.respondJSON(w, http.StatusNotImplemented, errorResponse{Error: "rollback not configured"})
+			return
+		}
+		if err := a.rollbackFn(); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, reconcile.ErrNoPreviousGeneration) {
+				status = http.StatusConflict
+			}
+			a.respondJSON(w, status, errorResponse{Error: err.Error()})
+			return
+		}
 		a.respondJSON(w, http.StatusOK, statusResponse{Status: "ok"})
 	})
 }
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -11,34 +12,129 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/heartbeat/reconcile"
 )
 
 func TestReloadHandler(t *testing.T) {
 	t.Parallel()
 
-	var calls atomic.Int32
-	logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
-
-	api := NewAPI(
-		"test",
-		"test",
-		"",
-		logger,
-	)
-	api.SetReloadFn(func() error {
-		calls.Add(1)
-		return nil
+	newAPI := func(calls *atomic.Int32, dryRuns *atomic.Int32) *API {
+		logger := slog.New(slog.NewTextHandler(&strings.Builder{}, nil))
+		api := NewAPI(
+			"test",
+			"test",
+			"",
+			logger,
+		)
+		api.SetReloadFn(func(dryRun bool) (config.Diff, error) {
+			calls.Add(1)
+			if dryRun {
+				dryRuns.Add(1)
+			}
+			return config.Diff{
+				Heartbeats: config.SectionDiff{
+					Added:   []string{"db"},
+					Changed: map[string][]string{"api": {"interval"}},
+				},
+			}, nil
+		})
+		return api
+	}
+
+	t.Run("reload", func(t *testing.T) {
+		t.Parallel()
+		var calls, dryRuns atomic.Int32
+		api := newAPI(&calls, &dryRuns)
+
+		req := httptest.NewRequest(http.MethodPost, "/-/reload", nil)
+		rec := httptest.NewRecorder()
+		api.ReloadHandler().ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp reloadResponse
+		err := json.NewDecoder(rec.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp.Status)
+		assert.False(t, resp.DryRun)
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Zero(t, dryRuns.Load())
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		t.Parallel()
+		var calls, dryRuns atomic.Int32
+		api := newAPI(&calls, &dryRuns)
+
+		req := httptest.NewRequest(http.MethodPost, "/-/reload?dry_run=true", nil)
+		rec := httptest.NewRecorder()
+		api.ReloadHandler().ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{
+			"status": "ok",
+			"dry_run": true,
+			"diff": {
+				"heartbeats": {"added": ["db"], "changed": {"api": ["interval"]}},
+				"receivers": {},
+				"digests": {}
+			}
+		}`, rec.Body.String())
+		assert.Equal(t, int32(1), dryRuns.Load())
+	})
+
+	t.Run("invalid dry run", func(t *testing.T) {
+		t.Parallel()
+		var calls, dryRuns atomic.Int32
+		api := newAPI(&calls, &dryRuns)
+
+		req := httptest.NewRequest(http.MethodPost, "/-/reload?dry_run=maybe", nil)
+		rec := httptest.NewRecorder()
+		api.ReloadHandler().ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Zero(t, calls.Load())
+	})
+}
+
+func TestRollbackHandler(t *testing.T) {
+	t.Parallel()
+
+	newAPI := func(err error) *API {
+		api := NewAPI("test", "test", "", slog.New(slog.NewTextHandler(&strings.Builder{}, nil)))
+		api.SetRollbackFn(func() error { return err })
+		return api
+	}
+	rollback := func(api *API) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.RollbackHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/-/rollback", nil))
+		return rec
+	}
+
+	t.Run("rollback", func(t *testing.T) {
+		t.Parallel()
+		rec := rollback(newAPI(nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
 	})
 
-	req := httptest.NewRequest(http.MethodPost, "/-/reload", nil)
-	rec := httptest.NewRecorder()
-	api.ReloadHandler().ServeHTTP(rec, req)
+	t.Run("no previous generation", func(t *testing.T) {
+		t.Parallel()
+		rec := rollback(newAPI(reconcile.ErrNoPreviousGeneration))
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
 
-	require.Equal(t, http.StatusOK, rec.Code)
+	t.Run("failed", func(t *testing.T) {
+		t.Parallel()
+		rec := rollback(newAPI(errors.New("boom")))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
 
-	var resp statusResponse
-	err := json.NewDecoder(rec.Body).Decode(&resp)
-	require.NoError(t, err)
-	assert.Equal(t, "ok", resp.Status)
-	assert.Equal(t, int32(1), calls.Load())
+	t.Run("not configured", func(t *testing.T) {
+		t.Parallel()
+		rec := rollback(NewAPI("test", "test", "", slog.New(slog.NewTextHandler(&strings.Builder{}, nil))))
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+	})
 }
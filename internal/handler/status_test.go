@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/containeroo/heartbeats/internal/heartbeat/service"
+	"github.com/containeroo/heartbeats/internal/runner"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,10 +27,22 @@ func (f fakeStatusService) ReceiverSummaries() []service.ReceiverSummary {
 	return nil
 }
 
-func (f fakeStatusService) Update(id string, payload string, now time.Time) error {
+func (f fakeStatusService) Provision(id string, creds service.PingCredentials, payload string, now time.Time) error {
 	return nil
 }
 
+func (f fakeStatusService) VerifyPing(id string, creds service.PingCredentials, payload string, now time.Time) error {
+	return nil
+}
+
+func (f fakeStatusService) Update(id, contentType, payload string, now time.Time) error {
+	return nil
+}
+
+func (f fakeStatusService) Payloads(id string) ([]runner.PayloadRecord, error) {
+	return nil, nil
+}
+
 func (f fakeStatusService) StatusAll() []service.Status {
 	return f.statuses
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/containeroo/heartbeats/internal/heartbeat/service"
+	"github.com/containeroo/heartbeats/internal/runner"
 	"github.com/stretchr/testify/require"
 )
 
@@ -24,10 +25,22 @@ func (f *fakeSummaryService) ReceiverSummaries() []service.ReceiverSummary {
 	return f.rc
 }
 
-func (f *fakeSummaryService) Update(id string, payload string, now time.Time) error {
+func (f *fakeSummaryService) Provision(id string, creds service.PingCredentials, payload string, now time.Time) error {
 	return nil
 }
 
+func (f *fakeSummaryService) VerifyPing(id string, creds service.PingCredentials, payload string, now time.Time) error {
+	return nil
+}
+
+func (f *fakeSummaryService) Update(id, contentType, payload string, now time.Time) error {
+	return nil
+}
+
+func (f *fakeSummaryService) Payloads(id string) ([]runner.PayloadRecord, error) {
+	return nil, nil
+}
+
 func (f *fakeSummaryService) StatusAll() []service.Status {
 	return nil
 }
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/containeroo/heartbeats/internal/notify"
+)
+
+// TemplateRenderFunc renders a template preview.
+type TemplateRenderFunc func(req notify.RenderRequest) (string, error)
+
+// SetTemplateRenderFn attaches the function rendering template previews.
+func (a *API) SetTemplateRenderFn(fn TemplateRenderFunc) {
+	a.renderFn = fn
+}
+
+// renderResponse is the result of a template preview.
+type renderResponse struct {
+	Output string `json:"output"`          // Rendered template.
+	Error  string `json:"error,omitempty"` // Parse or execution error.
+	Stage  string `json:"stage,omitempty"` // parse or execute, set with Error.
+}
+
+// RenderTemplate renders a template, or a receiver's configured template,
+// with sample data or the live data of a heartbeat. Template errors are
+// returned with 422 and the stage that failed.
+func (a *API) RenderTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.renderFn == nil {
+			a.respondJSON(w, http.StatusNotImplemented, errorResponse{Error: "template rendering not configured"})
+			return
+		}
+		var req notify.RenderRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxConfigBodyBytes)).Decode(&req); err != nil {
+			a.respondJSON(w, http.StatusBadRequest, errorResponse{Error: "decode body: " + err.Error()})
+			return
+		}
+		if req.Heartbeat != "" && a.service != nil {
+			a.liveRenderData(&req)
+		}
+
+		output, err := a.renderFn(req)
+		var renderErr *notify.RenderError
+		switch {
+		case errors.As(err, &renderErr):
+			a.respondJSON(w, http.StatusUnprocessableEntity, renderResponse{Error: renderErr.Err.Error(), Stage: renderErr.Stage})
+		case errors.Is(err, notify.ErrUnknownHeartbeat), errors.Is(err, notify.ErrUnknownReceiver):
+			a.respondJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+		case err != nil:
+			a.respondJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		default:
+			a.respondJSON(w, http.StatusOK, renderResponse{Output: output})
+		}
+	}
+}
+
+// liveRenderData fills the time since the last ping and, unless one was
+// given, the last payload of the requested heartbeat.
+func (a *API) liveRenderData(req *notify.RenderRequest) {
+	if status, err := a.service.StatusByID(req.Heartbeat); err == nil {
+		req.Since = status.SinceSeen
+	}
+	if req.Payload != "" {
+		return
+	}
+	if payloads, err := a.service.Payloads(req.Heartbeat); err == nil && len(payloads) > 0 {
+		req.Payload = payloads[0].Body
+	}
+}
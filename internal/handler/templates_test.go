@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/notify"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	t.Parallel()
+
+	bodyPath := filepath.Join(t.TempDir(), "ops.tmpl")
+	require.NoError(t, os.WriteFile(bodyPath, []byte(`{{ .Subject }} via {{ index .Vars "channel" }}`), 0o600))
+	cfg := &config.Config{
+		Receivers: map[string]config.ReceiverConfig{
+			"ops": {
+				Webhooks: []config.WebhookConfig{{URL: "https://example.com", Template: bodyPath}},
+				Vars:     map[string]any{"channel": "#ops"},
+			},
+		},
+		Heartbeats: map[string]config.HeartbeatConfig{
+			"api": {Title: "API", Interval: time.Minute, LateAfter: time.Minute, SubjectTmpl: "{{ .Title }} is {{ .Status }}", Receivers: []string{"ops"}},
+		},
+	}
+	api := NewAPI("test", "test", "", slog.New(slog.NewTextHandler(&strings.Builder{}, nil)))
+	api.SetTemplateRenderFn(func(req notify.RenderRequest) (string, error) {
+		return notify.Render(fstest.MapFS{}, cfg, req)
+	})
+	render := func(body string) (*httptest.ResponseRecorder, renderResponse) {
+		rec := httptest.NewRecorder()
+		api.RenderTemplate().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/templates/render", strings.NewReader(body)))
+		var resp renderResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp
+	}
+
+	t.Run("inline template with sample data", func(t *testing.T) {
+		t.Parallel()
+		rec, resp := render(`{"template": "{{ .Title }}: {{ .Status }} {{ .Payload }}", "status": "missing"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `Example: missing {"job":"backup","status":"ok"}`, resp.Output)
+	})
+
+	t.Run("receiver template for a heartbeat", func(t *testing.T) {
+		t.Parallel()
+		rec, resp := render(`{"receiver": "ops", "heartbeat": "api", "status": "recovered"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "API is recovered via "+config.RedactedSecret, resp.Output)
+	})
+
+	t.Run("receiver vars stay redacted", func(t *testing.T) {
+		t.Parallel()
+		rec, resp := render(`{"receiver": "ops", "template": "{{ .Vars }}"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, resp.Output, "#ops")
+		assert.Contains(t, resp.Output, config.RedactedSecret)
+	})
+
+	t.Run("receiver subject", func(t *testing.T) {
+		t.Parallel()
+		rec, resp := render(`{"receiver": "ops", "heartbeat": "api", "subject": true}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "API is late", resp.Output)
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		t.Parallel()
+		rec, resp := render(`{"template": "{{ .Title "}`)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, "parse", resp.Stage)
+		assert.Contains(t, resp.Error, "template:1")
+	})
+
+	t.Run("execution error", func(t *testing.T) {
+		t.Parallel()
+		rec, resp := render(`{"template": "{{ .Missing }}"}`)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, "execute", resp.Stage)
+		assert.Contains(t, resp.Error, "can't evaluate field Missing")
+	})
+
+	t.Run("unknown heartbeat", func(t *testing.T) {
+		t.Parallel()
+		rec, _ := render(`{"template": "x", "heartbeat": "db"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("nothing to render", func(t *testing.T) {
+		t.Parallel()
+		rec, _ := render(`{}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
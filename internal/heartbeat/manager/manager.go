@@ -3,6 +3,7 @@ package manager
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"reflect"
 	"strings"
@@ -17,6 +18,7 @@ import (
 	"github.com/containeroo/heartbeats/internal/logging"
 	"github.com/containeroo/heartbeats/internal/metrics"
 	"github.com/containeroo/heartbeats/internal/notify"
+	"github.com/containeroo/heartbeats/internal/payload"
 	"github.com/containeroo/heartbeats/internal/runner"
 	"github.com/containeroo/heartbeats/internal/utils"
 )
@@ -31,6 +33,7 @@ type Manager struct {
 	logger     *slog.Logger
 	metrics    *metrics.Registry
 	routes     notify.ReceiverRoutes
+	version    uint64 // incremented by every commit, to detect stale plans
 }
 
 // NewManager builds a Manager from config.
@@ -46,7 +49,10 @@ func NewManager(
 		return nil, errors.New("config is nil")
 	}
 
-	heartbeatMap := buildHeartbeatMap(cfg, routes, nil)
+	heartbeatMap, err := buildHeartbeatMap(cfg, routes, nil)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Manager{
 		heartbeats: heartbeatMap,
@@ -81,11 +87,8 @@ func (m *Manager) StopAll() {
 	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	for id, cancel := range m.cancels {
-		if cancel != nil {
-			cancel()
-		}
-		delete(m.cancels, id)
+	for id := range m.cancels {
+		m.stopHeartbeat(id)
 	}
 }
 
@@ -121,16 +124,43 @@ type ReloadResult struct {
 	Removed int
 }
 
+// ReloadPlan is a reload built by Prepare and applied by Commit.
+type ReloadPlan struct {
+	heartbeats map[string]*htypes.Heartbeat
+	routes     notify.ReceiverRoutes
+	result     ReloadResult
+	version    uint64
+}
+
+// Result reports the heartbeat changes the plan applies.
+func (p *ReloadPlan) Result() ReloadResult {
+	return p.result
+}
+
 // Reload rebuilds heartbeat runners and receiver routes.
 func (m *Manager) Reload(ctx context.Context, cfg *config.Config, routes notify.ReceiverRoutes) (ReloadResult, error) {
+	plan, err := m.Prepare(cfg, routes)
+	if err != nil {
+		return ReloadResult{}, err
+	}
+	if err := m.Commit(ctx, plan); err != nil {
+		return ReloadResult{}, err
+	}
+	return plan.result, nil
+}
+
+// Prepare builds the heartbeats of cfg without touching the running ones.
+// States of existing heartbeats are carried over when the plan is committed.
+func (m *Manager) Prepare(cfg *config.Config, routes notify.ReceiverRoutes) (*ReloadPlan, error) {
 	if m == nil {
-		return ReloadResult{}, errors.New("manager is nil")
+		return nil, errors.New("manager is nil")
 	}
 	if cfg == nil {
-		return ReloadResult{}, errors.New("config is nil")
+		return nil, errors.New("config is nil")
 	}
 
 	m.mu.RLock()
+	version := m.version
 	oldSnapshot := make(map[string]*htypes.Heartbeat, len(m.heartbeats))
 	oldStates := make(map[string]*runner.State, len(m.heartbeats))
 	for id, hb := range m.heartbeats {
@@ -141,17 +171,75 @@ func (m *Manager) Reload(ctx context.Context, cfg *config.Config, routes notify.
 	}
 	m.mu.RUnlock()
 
-	nextMap := buildHeartbeatMap(cfg, routes, oldStates)
-	result := diffHeartbeatSets(oldSnapshot, nextMap)
+	nextMap, err := buildHeartbeatMap(cfg, routes, oldStates)
+	if err != nil {
+		return nil, err
+	}
+	return &ReloadPlan{
+		heartbeats: nextMap,
+		routes:     routes,
+		result:     diffHeartbeatSets(oldSnapshot, nextMap),
+		version:    version,
+	}, nil
+}
+
+// Commit swaps in a prepared plan. Only runners of added or changed
+// heartbeats are (re)started and only those of removed or changed ones are
+// stopped; unchanged heartbeats keep their runner and its pending timers.
+// Commit fails without changing anything when another commit happened since
+// the plan was prepared.
+func (m *Manager) Commit(ctx context.Context, plan *ReloadPlan) error {
+	if m == nil {
+		return errors.New("manager is nil")
+	}
+	if plan == nil {
+		return errors.New("reload plan is nil")
+	}
 
-	m.StopAll()
 	m.mu.Lock()
-	m.heartbeats = nextMap
-	m.routes = routes
-	m.mu.Unlock()
-	m.StartAll(ctx)
+	defer m.mu.Unlock()
+	if plan.version != m.version {
+		return errors.New("reload plan is stale")
+	}
+	m.version++
+	if m.cancels == nil {
+		m.cancels = make(map[string]context.CancelFunc)
+	}
+
+	next := make(map[string]*htypes.Heartbeat, len(plan.heartbeats))
+	var start []*htypes.Heartbeat
+	for id, hb := range plan.heartbeats {
+		prev, ok := m.heartbeats[id]
+		if ok && !heartbeatChanged(prev, hb) {
+			next[id] = prev
+			if _, running := m.cancels[id]; running {
+				continue
+			}
+		} else {
+			next[id] = hb
+		}
+		start = append(start, next[id])
+	}
+	for id := range m.heartbeats {
+		if _, ok := next[id]; !ok {
+			m.stopHeartbeat(id)
+		}
+	}
+
+	m.heartbeats = next
+	m.routes = plan.routes
+	for _, hb := range start {
+		m.startHeartbeat(ctx, hb)
+	}
+	return nil
+}
 
-	return result, nil
+// stopHeartbeat stops the runner of a heartbeat, if running.
+func (m *Manager) stopHeartbeat(id string) {
+	if cancel := m.cancels[id]; cancel != nil {
+		cancel()
+	}
+	delete(m.cancels, id)
 }
 
 // startHeartbeat starts a single heartbeat runner.
@@ -194,7 +282,7 @@ func buildHeartbeatMap(
 	cfg *config.Config,
 	routes notify.ReceiverRoutes,
 	states map[string]*runner.State,
-) map[string]*htypes.Heartbeat {
+) (map[string]*htypes.Heartbeat, error) {
 	heartbeatMap := make(map[string]*htypes.Heartbeat, len(cfg.Heartbeats))
 	for id, sc := range cfg.Heartbeats {
 		title := strings.TrimSpace(sc.Title)
@@ -202,12 +290,18 @@ func buildHeartbeatMap(
 			title = id
 		}
 
+		rules, err := payload.NewRules(sc.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("heartbeat %q payload: %w", id, err)
+		}
+
 		state := runner.NewState()
 		if states != nil {
 			if existing := states[id]; existing != nil {
 				state = existing
 			}
 		}
+		state.SetPayloadLimits(cfg.History.Payloads, cfg.History.PayloadMaxBytes)
 		heartbeatMap[id] = &htypes.Heartbeat{
 			ID:              id,
 			Title:           title,
@@ -217,10 +311,12 @@ func buildHeartbeatMap(
 			State:           state,
 			AlertOnLate:     *utils.DefaultIfZero(sc.AlertOnLate, utils.ToPtr(false)),
 			AlertOnRecovery: *utils.DefaultIfZero(sc.AlertOnRecovery, utils.ToPtr(true)),
+			PayloadRules:    rules,
+			PayloadMetrics:  payload.NewExtractor(sc.Metrics),
 		}
 	}
 
-	return heartbeatMap
+	return heartbeatMap, nil
 }
 
 // diffHeartbeatSets compares two heartbeat sets.
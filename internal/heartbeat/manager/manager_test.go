@@ -1,9 +1,12 @@
 package manager
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"log/slog"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -88,6 +91,106 @@ func TestManagerReloadErrors(t *testing.T) {
 	})
 }
 
+func TestManagerPrepareCommit(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("prepare leaves running heartbeats alone", func(t *testing.T) {
+		t.Parallel()
+		mgr := setupManager(t)
+		defer mgr.StopAll()
+
+		cfg := sampleConfig()
+		cfg.Heartbeats["db"] = cfg.Heartbeats["api"]
+		plan, err := mgr.Prepare(cfg, sampleRoutes())
+		require.NoError(t, err)
+		require.Equal(t, 1, plan.Result().Added)
+		_, ok := mgr.Get("db")
+		require.False(t, ok)
+
+		require.NoError(t, mgr.Commit(ctx, plan))
+		_, ok = mgr.Get("db")
+		require.True(t, ok)
+	})
+
+	t.Run("stale plan", func(t *testing.T) {
+		t.Parallel()
+		mgr := setupManager(t)
+		defer mgr.StopAll()
+
+		first, err := mgr.Prepare(sampleConfig(), sampleRoutes())
+		require.NoError(t, err)
+		second, err := mgr.Prepare(sampleConfig(), sampleRoutes())
+		require.NoError(t, err)
+
+		require.NoError(t, mgr.Commit(ctx, first))
+		require.EqualError(t, mgr.Commit(ctx, second), "reload plan is stale")
+	})
+}
+
+// lockedBuffer is a log sink safe for concurrent writes.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestManagerReloadRestartsChangedRunners(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	logs := &lockedBuffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	cfg := sampleConfig()
+	cfg.Heartbeats["db"] = cfg.Heartbeats["api"]
+	mgr, err := NewManager(cfg, noopNotifier{}, sampleRoutes(), history.NewStore(10), metrics.NewRegistry(), logger)
+	require.NoError(t, err)
+	mgr.StartAll(ctx)
+	defer mgr.StopAll()
+	api, _ := mgr.Get("api")
+
+	next := sampleConfig()
+	db := next.Heartbeats["api"]
+	db.Interval *= 2
+	next.Heartbeats["db"] = db
+	res, err := mgr.Reload(ctx, next, sampleRoutes())
+	require.NoError(t, err)
+	require.Equal(t, ReloadResult{Updated: 1}, res)
+
+	started := func(id string) int {
+		count := 0
+		for line := range strings.SplitSeq(logs.String(), "\n") {
+			if strings.Contains(line, "Started heartbeat runner") && strings.Contains(line, `"heartbeat":"`+id+`"`) {
+				count++
+			}
+		}
+		return count
+	}
+	require.Equal(t, 1, started("api"), "unchanged runners keep running")
+	require.Equal(t, 2, started("db"))
+	reloaded, _ := mgr.Get("api")
+	require.Same(t, api, reloaded)
+
+	delete(next.Heartbeats, "db")
+	_, err = mgr.Reload(ctx, next, sampleRoutes())
+	require.NoError(t, err)
+	mgr.mu.RLock()
+	defer mgr.mu.RUnlock()
+	require.NotContains(t, mgr.cancels, "db")
+	require.Contains(t, mgr.cancels, "api")
+}
+
 func TestDiffHeartbeatSets(t *testing.T) {
 	t.Parallel()
 	t.Run("diff counts", func(t *testing.T) {
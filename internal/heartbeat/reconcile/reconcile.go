@@ -2,49 +2,89 @@ package reconcile
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 
 	kit "github.com/containeroo/notifykit/notify"
 
 	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/filewatch"
 	"github.com/containeroo/heartbeats/internal/heartbeat/manager"
 	"github.com/containeroo/heartbeats/internal/notify"
 )
 
-// Reload loads, validates, and applies the config to the manager.
-func Reload(
-	ctx context.Context,
-	filePath string,
-	templateFS fs.FS,
-	receivers kit.Receivers,
-	routes notify.ReceiverRoutes,
-	opts config.LoadOptions,
-	logger *slog.Logger,
-	mgr *manager.Manager,
-) (manager.ReloadResult, error) {
+// Hook is called with the applied config after a successful reload.
+type Hook func(cfg *config.Config)
+
+// Stage prepares a component for cfg while a reload is validated. An error
+// fails the reload before anything is switched; the returned commit is
+// called inside the reload transaction once the heartbeats are switched, and
+// must not fail.
+type Stage func(cfg *config.Config) (commit func(), err error)
+
+// ErrNoPreviousGeneration is returned by Rollback before any reload changed
+// the config.
+var ErrNoPreviousGeneration = errors.New("no previous generation")
+
+// generation is a loaded config with the receivers and routes built from
+// it. It is never modified once built, so it can be shared with readers and
+// replaced as a whole.
+type generation struct {
+	cfg       *config.Config
+	receivers kit.Receivers
+	routes    notify.ReceiverRoutes
+	id        Generation
+}
+
+// load reads the config and builds its receivers without applying anything.
+func load(filePath string, templateFS fs.FS, opts config.LoadOptions, logger *slog.Logger) (*generation, error) {
 	cfg, err := config.LoadWithOptions(filePath, opts)
 	if err != nil {
-		return manager.ReloadResult{}, fmt.Errorf("failed to load config: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 	nextReceivers, nextRoutes, err := notify.ReceiversFromConfig(templateFS, cfg, logger)
 	if err != nil {
-		return manager.ReloadResult{}, fmt.Errorf("build receivers: %w", err)
+		return nil, fmt.Errorf("build receivers: %w", err)
 	}
-	notify.ReplaceReceivers(receivers, nextReceivers)
-	notify.ReplaceRoutes(routes, nextRoutes)
-	res, err := mgr.Reload(ctx, cfg, routes)
-	if err != nil {
-		return res, err
-	}
-	return res, nil
+	return &generation{cfg: cfg, receivers: nextReceivers, routes: nextRoutes}, nil
+}
+
+// Generation identifies the applied config.
+type Generation struct {
+	Number uint64 // 1 for the startup config, incremented by every reload that changes the config.
+	Hash   string // SHA-256 of the resolved config; see config.Hash.
 }
 
-// NewReloadFunc builds a reload function with logging and serialization.
-func NewReloadFunc(
+// Reloader serializes reloads and holds the applied generation: the config
+// with its receivers and routes. A reload builds the next generation
+// completely, prepares the heartbeat switch, and then replaces the active
+// generation with one atomic pointer swap, so readers never see a partly
+// applied config. The previous generation is kept to roll back to.
+type Reloader struct {
+	ctx        context.Context
+	filePath   string
+	templateFS fs.FS
+	opts       config.LoadOptions
+	logger     *slog.Logger
+	mgr        *manager.Manager
+	hooks      []Hook
+
+	mu       sync.Mutex // serializes reloads
+	stages   []Stage    // guarded by mu
+	active   atomic.Pointer[generation]
+	previous *generation // generation replaced by the last change; guarded by mu
+}
+
+// NewReloader builds a reloader; current is the config the app started with
+// and receivers and routes were built from it. They must not be modified
+// afterwards.
+func NewReloader(
 	ctx context.Context,
 	filePath string,
 	templateFS fs.FS,
@@ -53,25 +93,156 @@ func NewReloadFunc(
 	opts config.LoadOptions,
 	logger *slog.Logger,
 	mgr *manager.Manager,
-) func() error {
-	var reloadMu sync.Mutex
-	return func() error {
-		reloadMu.Lock()
-		defer reloadMu.Unlock()
+	current *config.Config,
+	hooks ...Hook,
+) *Reloader {
+	r := &Reloader{
+		ctx:        ctx,
+		filePath:   filePath,
+		templateFS: templateFS,
+		opts:       opts,
+		logger:     logger,
+		mgr:        mgr,
+		hooks:      hooks,
+	}
+	r.active.Store(&generation{
+		cfg:       current,
+		receivers: receivers,
+		routes:    routes,
+		id:        Generation{Number: 1, Hash: config.Hash(current)},
+	})
+	return r
+}
+
+// Generation returns the generation of the applied config.
+func (r *Reloader) Generation() Generation {
+	return r.active.Load().id
+}
+
+// Config returns the applied config.
+func (r *Reloader) Config() *config.Config {
+	return r.active.Load().cfg
+}
+
+// Receivers returns the receivers of the applied config. The map is shared
+// and must not be modified.
+func (r *Reloader) Receivers() kit.Receivers {
+	return r.active.Load().receivers
+}
+
+// AddStage registers a component that is prepared and switched together
+// with every later reload.
+func (r *Reloader) AddStage(stage Stage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stages = append(r.stages, stage)
+}
+
+// Reload loads the config and returns how it differs from the applied one.
+// Unless dryRun is set, the config is then applied.
+func (r *Reloader) Reload(dryRun bool) (config.Diff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := load(r.filePath, r.templateFS, r.opts, r.logger)
+	if err != nil {
+		return config.Diff{}, err
+	}
+	current := r.active.Load()
+	diff := config.DiffConfigs(current.cfg, next.cfg)
+	if dryRun {
+		_, err := r.prepareStages(next.cfg)
+		return diff, err
+	}
 
-		res, err := Reload(ctx, filePath, templateFS, receivers, routes, opts, logger, mgr)
+	next.id = current.id
+	if hash := config.Hash(next.cfg); hash != current.id.Hash {
+		next.id = Generation{Number: current.id.Number + 1, Hash: hash}
+	}
+	res, err := r.apply(next)
+	if err != nil {
+		return config.Diff{}, err
+	}
+	if res.Added+res.Updated+res.Removed > 0 {
+		r.logger.Info("heartbeats reloaded",
+			"added", res.Added,
+			"updated", res.Updated,
+			"removed", res.Removed,
+			"generation", next.id.Number,
+		)
+	}
+	return diff, nil
+}
+
+// Rollback re-applies the generation replaced by the last reload that
+// changed the config. It counts as a new generation with the hash of the
+// restored config; the next reload reads the config files again.
+func (r *Reloader) Rollback() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.previous == nil {
+		return ErrNoPreviousGeneration
+	}
+	next := *r.previous
+	next.id = Generation{Number: r.active.Load().id.Number + 1, Hash: next.id.Hash}
+	if _, err := r.apply(&next); err != nil {
+		return err
+	}
+	r.logger.Info("config rolled back", "generation", next.id.Number)
+	return nil
+}
+
+// apply makes next the active generation. Everything that can fail is
+// prepared first; if the manager still rejects the switch, the previous
+// generation is restored so receivers and routes never disagree with the
+// running heartbeats. Stages are committed only once the manager switched.
+// Callers hold r.mu.
+func (r *Reloader) apply(next *generation) (manager.ReloadResult, error) {
+	commits, err := r.prepareStages(next.cfg)
+	if err != nil {
+		return manager.ReloadResult{}, err
+	}
+	plan, err := r.mgr.Prepare(next.cfg, next.routes)
+	if err != nil {
+		return manager.ReloadResult{}, err
+	}
+
+	prev := r.active.Swap(next)
+	if err := r.mgr.Commit(r.ctx, plan); err != nil {
+		r.active.Store(prev)
+		return manager.ReloadResult{}, fmt.Errorf("rolled back: %w", err)
+	}
+	for _, commit := range commits {
+		commit()
+	}
+	if prev.id != next.id {
+		r.previous = prev
+	}
+
+	for _, hook := range r.hooks {
+		hook(next.cfg)
+	}
+	return plan.Result(), nil
+}
+
+// prepareStages prepares every stage for cfg. Callers hold r.mu.
+func (r *Reloader) prepareStages(cfg *config.Config) ([]func(), error) {
+	commits := make([]func(), 0, len(r.stages))
+	for _, stage := range r.stages {
+		commit, err := stage(cfg)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if res.Added+res.Updated+res.Removed > 0 {
-			logger.Info("heartbeats reloaded",
-				"added", res.Added,
-				"updated", res.Updated,
-				"removed", res.Removed,
-			)
-		}
-		return nil
+		commits = append(commits, commit)
 	}
+	return commits, nil
+}
+
+// Apply reloads the config, for callers that only need the error.
+func (r *Reloader) Apply() error {
+	_, err := r.Reload(false)
+	return err
 }
 
 // WatchReload listens for reload signals and invokes the reload function.
@@ -90,3 +261,41 @@ func WatchReload(ctx context.Context, reloadCh <-chan os.Signal, logger *slog.Lo
 		}
 	}
 }
+
+// WatchConfig invokes the reload function when the files selected by
+// configPath or the templates in templateDir change, until ctx is canceled.
+func WatchConfig(ctx context.Context, configPath, templateDir string, logger *slog.Logger, reloadFn func() error) {
+	dirs := configDirs(configPath)
+	if templateDir != "" {
+		dirs = append(dirs, templateDir)
+	}
+	watcher := filewatch.New(filewatch.Options{
+		Dirs: dirs,
+		Files: func() ([]string, error) {
+			files, err := config.Files(configPath)
+			if err != nil || templateDir == "" {
+				return files, err
+			}
+			tmplFiles, err := filepath.Glob(filepath.Join(templateDir, "*.tmpl"))
+			return append(files, tmplFiles...), err
+		},
+	}, logger)
+	watcher.Run(ctx, func() {
+		logger.Info("config change detected")
+		if err := reloadFn(); err != nil {
+			logger.Error("reload failed", "err", err)
+			return
+		}
+		logger.Info("reload completed")
+	})
+}
+
+// configDirs returns the directory to watch for configPath. Watching the
+// directory rather than the file also catches atomic renames and ConfigMap
+// symlink swaps.
+func configDirs(configPath string) []string {
+	if info, err := os.Stat(configPath); err == nil && info.IsDir() {
+		return []string{configPath}
+	}
+	return []string{filepath.Dir(configPath)}
+}
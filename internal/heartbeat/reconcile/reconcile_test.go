@@ -52,43 +52,52 @@ func TestReload(t *testing.T) {
 		t.Parallel()
 
 		cfgPath := writeConfig(t, sampleConfigYAML)
-		cfg, err := config.Load(cfgPath)
+		reloader, _ := newReloader(t, cfgPath, logger)
+		diff, err := reloader.Reload(false)
 		require.NoError(t, err)
-		receivers, routes, err := appnotify.ReceiversFromConfig(templateFS, cfg, logger)
-		require.NoError(t, err)
-		mgr, err := manager.NewManager(cfg, noopNotifier{}, routes, history.NewStore(10), metrics.NewRegistry(), logger)
-		require.NoError(t, err)
-
-		res, err := Reload(context.Background(), cfgPath, templateFS, receivers, routes, config.LoadOptions{}, logger, mgr)
-		require.NoError(t, err)
-		require.Zero(t, res.Added+res.Updated+res.Removed)
+		require.True(t, diff.Empty())
 	})
 
 	t.Run("load error", func(t *testing.T) {
 		t.Parallel()
 
-		_, err := Reload(context.Background(), filepath.Join(t.TempDir(), "missing.yaml"), templateFS, kit.Receivers{}, appnotify.ReceiverRoutes{}, config.LoadOptions{}, logger, &manager.Manager{})
+		_, err := load(filepath.Join(t.TempDir(), "missing.yaml"), templateFS, config.LoadOptions{}, logger)
 		require.Error(t, err)
 	})
 }
 
-func TestNewReloadFunc(t *testing.T) {
-	t.Parallel()
-
+// newReloader builds a reloader and manager for the config at cfgPath.
+func newReloader(t *testing.T, cfgPath string, logger *slog.Logger) (*Reloader, *manager.Manager) {
+	t.Helper()
 	templateFS := os.DirFS(filepath.Join("..", "..", "..", "templates"))
-	ctx := context.Background()
-	logBuf := &bytes.Buffer{}
-	logger := slog.New(slog.NewJSONHandler(logBuf, &slog.HandlerOptions{Level: slog.LevelDebug}))
-
-	cfgPath := writeConfig(t, sampleConfigYAML)
 	cfg, err := config.Load(cfgPath)
 	require.NoError(t, err)
 	receivers, routes, err := appnotify.ReceiversFromConfig(templateFS, cfg, logger)
 	require.NoError(t, err)
 	mgr, err := manager.NewManager(cfg, noopNotifier{}, routes, history.NewStore(10), metrics.NewRegistry(), logger)
 	require.NoError(t, err)
+	return NewReloader(context.Background(), cfgPath, templateFS, receivers, routes, config.LoadOptions{}, logger, mgr, cfg), mgr
+}
 
-	reloadFn := NewReloadFunc(ctx, cfgPath, templateFS, receivers, routes, config.LoadOptions{}, logger, mgr)
+func TestReloader(t *testing.T) {
+	t.Parallel()
+
+	logBuf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(logBuf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	cfgPath := writeConfig(t, sampleConfigYAML)
+	reloader, mgr := newReloader(t, cfgPath, logger)
+	cfg := reloader.Config()
+	receivers := reloader.Receivers()
+	startup := reloader.Generation()
+	require.Equal(t, uint64(1), startup.Number)
+	require.Equal(t, config.Hash(cfg), startup.Hash)
+	require.ErrorIs(t, reloader.Rollback(), ErrNoPreviousGeneration)
+
+	var staged []*config.Config
+	reloader.AddStage(func(next *config.Config) (func(), error) {
+		return func() { staged = append(staged, next) }, nil
+	})
 
 	require.NoError(t, os.WriteFile(cfgPath, []byte(`
 receivers:
@@ -111,8 +120,94 @@ history:
   size: 10
 `), 0o600))
 
-	require.NoError(t, reloadFn())
+	diff, err := reloader.Reload(true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"db"}, diff.Heartbeats.Added)
+	require.Equal(t, []string{"pager"}, diff.Receivers.Added)
+	require.NotContains(t, logBuf.String(), "heartbeats reloaded", "dry runs apply nothing")
+	_, ok := mgr.Get("db")
+	require.False(t, ok)
+	require.Empty(t, staged, "dry runs commit no stages")
+
+	diff, err = reloader.Reload(false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"db"}, diff.Heartbeats.Added)
 	require.Contains(t, logBuf.String(), "heartbeats reloaded")
+	require.Contains(t, reloader.Receivers(), kit.ReceiverID("heartbeat.db.receiver.pager"))
+	require.NotContains(t, receivers, kit.ReceiverID("heartbeat.db.receiver.pager"), "applied generations are never modified")
+	applied := reloader.Generation()
+	require.Equal(t, uint64(2), applied.Number)
+	require.NotEqual(t, startup.Hash, applied.Hash)
+	require.Equal(t, []*config.Config{reloader.Config()}, staged)
+
+	diff, err = reloader.Reload(false)
+	require.NoError(t, err)
+	require.True(t, diff.Empty(), "the applied config is the new baseline")
+	require.Equal(t, applied, reloader.Generation(), "unchanged configs keep their generation")
+
+	require.NoError(t, reloader.Rollback())
+	require.Equal(t, cfg, reloader.Config(), "rollback restores the replaced generation")
+	require.NotContains(t, reloader.Receivers(), kit.ReceiverID("heartbeat.db.receiver.pager"))
+	_, ok = mgr.Get("db")
+	require.False(t, ok)
+	require.Equal(t, Generation{Number: 3, Hash: startup.Hash}, reloader.Generation())
+}
+
+func TestReloaderStageError(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+	cfgPath := writeConfig(t, sampleConfigYAML)
+	reloader, mgr := newReloader(t, cfgPath, logger)
+	startup := reloader.Generation()
+	reloader.AddStage(func(*config.Config) (func(), error) {
+		return nil, errors.New("invalid auth")
+	})
+
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`
+receivers:
+  ops:
+    webhooks:
+      - url: https://example.com
+heartbeats:
+  db:
+    interval: 2s
+    late_after: 3s
+    receivers: ["ops"]
+`), 0o600))
+
+	_, err := reloader.Reload(true)
+	require.EqualError(t, err, "invalid auth", "dry runs prepare stages")
+	_, err = reloader.Reload(false)
+	require.EqualError(t, err, "invalid auth")
+	require.Equal(t, startup, reloader.Generation())
+	_, ok := mgr.Get("db")
+	require.False(t, ok, "nothing is switched")
+}
+
+func TestReloaderConcurrentReads(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.DiscardHandler)
+	cfgPath := writeConfig(t, sampleConfigYAML)
+	reloader, _ := newReloader(t, cfgPath, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			// Every snapshot is a complete generation.
+			require.Len(t, reloader.Receivers(), len(reloader.Config().Heartbeats))
+		}
+	}()
+	for range 20 {
+		_, err := reloader.Reload(false)
+		require.NoError(t, err)
+	}
+	cancel()
+	<-done
 }
 
 func TestWatchReload(t *testing.T) {
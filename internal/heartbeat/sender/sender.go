@@ -74,6 +74,23 @@ func (s *HeartbeatSender) Recovered(now time.Time, payload string) {
 	))
 }
 
+// Failed handles a heartbeat that reported a failure.
+func (s *HeartbeatSender) Failed(now time.Time, reason string, payload string) {
+	event := notify.NewEvent(
+		s.Heartbeat.ID,
+		s.Heartbeat.Title,
+		htypes.StatusFailed.String(),
+		payload,
+		0,
+		now,
+		s.Heartbeat.Config.Interval,
+		s.Heartbeat.Config.LateAfter,
+		s.Heartbeat.ReceiverIDs,
+	)
+	event.Reason = reason
+	s.enqueue(event)
+}
+
 // Transition handles a stage transition event.
 func (s *HeartbeatSender) Transition(now time.Time, from runner.Stage, to runner.Stage, since time.Duration) {
 	s.Logger.Info("Runner stage transitioned",
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/history"
+)
+
+// ErrPingUnauthorized marks a ping with a missing or invalid token or signature.
+var ErrPingUnauthorized = errors.New("ping unauthorized")
+
+// PingCredentials are the secrets presented with a heartbeat ping.
+type PingCredentials struct {
+	Token     string // Token from the path, header, or query.
+	Signature string // Hex HMAC-SHA256 of the body, optionally prefixed with "sha256=".
+
+	// ClientSubjects identify the verified TLS client certificate: common name,
+	// distinguished name, and DNS names. Empty without a verified certificate.
+	ClientSubjects []string
+}
+
+// VerifyPing checks a ping against the heartbeat's token, HMAC secret, and
+// allowed client certificate subjects.
+// Rejections are recorded in history and counted before returning an error
+// wrapping ErrPingUnauthorized.
+func (s *Service) VerifyPing(id string, creds PingCredentials, body string, now time.Time) error {
+	hb, ok := s.manager.Get(id)
+	if !ok {
+		return fmt.Errorf("heartbeat %q not found", id)
+	}
+	ping := hb.Config.Ping
+	if ping == nil {
+		return nil
+	}
+
+	reason, label := rejectPing(*ping, creds, body)
+	if reason == "" {
+		return nil
+	}
+
+	s.rejectHeartbeat(id, now, reason, label)
+	return fmt.Errorf("%w: %s", ErrPingUnauthorized, reason)
+}
+
+// rejectHeartbeat records a rejected ping in history and counts it.
+func (s *Service) rejectHeartbeat(id string, now time.Time, reason, label string) {
+	s.recordHeartbeatRejected(id, now, reason)
+	if s.metrics != nil {
+		s.metrics.IncHeartbeatRejected(id, label)
+	}
+}
+
+// rejectPing returns why creds do not satisfy ping and the metric label for
+// it, or empty strings when the ping is accepted.
+func rejectPing(ping config.PingConfig, creds PingCredentials, body string) (reason, label string) {
+	switch {
+	case ping.Token != "" && creds.Token == "":
+		return "missing token", "token"
+	case ping.Token != "" && subtle.ConstantTimeCompare([]byte(creds.Token), []byte(ping.Token)) != 1:
+		return "invalid token", "token"
+	case len(ping.ClientCertSubjects) > 0 && len(creds.ClientSubjects) == 0:
+		return "missing client certificate", "client_cert"
+	case len(ping.ClientCertSubjects) > 0 && !slices.ContainsFunc(creds.ClientSubjects, func(subject string) bool {
+		return slices.Contains(ping.ClientCertSubjects, subject)
+	}):
+		return "client certificate not allowed", "client_cert"
+	case ping.HMACSecret != "" && creds.Signature == "":
+		return "missing signature", "signature"
+	case ping.HMACSecret != "" && !validSignature(ping.HMACSecret, body, creds.Signature):
+		return "invalid signature", "signature"
+	default:
+		return "", ""
+	}
+}
+
+// validSignature compares a hex HMAC-SHA256 signature of body.
+func validSignature(secret, body, signature string) bool {
+	got, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func (s *Service) recordHeartbeatRejected(id string, now time.Time, reason string) {
+	if s.history == nil {
+		return
+	}
+	s.history.Add(history.Event{
+		Time:        now,
+		Type:        history.EventHeartbeatRejected.String(),
+		HeartbeatID: id,
+		Message:     reason,
+	})
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/history"
+	"github.com/containeroo/heartbeats/internal/utils"
+)
+
+// Provisioner creates auto-provisioned heartbeats.
+type Provisioner interface {
+	ProvisionHeartbeat(id string, hb config.HeartbeatConfig, limit int) error
+}
+
+// autoProvision is the active auto-provisioning config.
+type autoProvision struct {
+	rules     []config.AutoProvisionRule
+	templates map[string]config.HeartbeatConfig
+	limit     int
+}
+
+// SetProvisioner sets the store that creates auto-provisioned heartbeats.
+func (s *Service) SetProvisioner(p Provisioner) {
+	s.provisionMu.Lock()
+	defer s.provisionMu.Unlock()
+	s.provisioner = p
+}
+
+// SetAutoProvision applies the auto-provisioning rules of cfg, e.g. after a reload.
+func (s *Service) SetAutoProvision(cfg *config.Config) {
+	next := autoProvision{}
+	if cfg.AutoProvision != nil {
+		next = autoProvision{
+			rules:     cfg.AutoProvision.Rules,
+			templates: cfg.HeartbeatTemplates,
+			limit:     utils.DefaultIfZero(cfg.AutoProvision.MaxHeartbeats, config.DefaultAutoProvisionMax),
+		}
+	}
+	s.provisionMu.Lock()
+	defer s.provisionMu.Unlock()
+	s.autoProvision = next
+}
+
+// Provision creates the heartbeat id from the template of the first matching
+// rule when id is unknown. It does nothing for known ids or when
+// auto-provisioning is disabled. Pings must satisfy the template's ping
+// settings before a heartbeat is created, so unauthenticated callers cannot
+// use up the limit.
+// Unknown ids that match no rule, fail the template's ping settings, or cannot
+// be created are rejected: each is recorded in history and counted without a
+// heartbeat label, since callers choose the id.
+func (s *Service) Provision(id string, creds PingCredentials, body string, now time.Time) error {
+	if _, ok := s.manager.Get(id); ok {
+		return nil
+	}
+	s.provisionMu.RLock()
+	provisioner, ap := s.provisioner, s.autoProvision
+	s.provisionMu.RUnlock()
+	if provisioner == nil {
+		return nil
+	}
+
+	for _, rule := range ap.rules {
+		if matched, _ := path.Match(rule.Pattern, id); !matched {
+			continue
+		}
+		tmpl := ap.templates[rule.Template]
+		if tmpl.Ping != nil {
+			if reason, label := rejectPing(*tmpl.Ping, creds, body); reason != "" {
+				s.rejectProvision(id, now, reason, label)
+				return fmt.Errorf("%w: %s", ErrPingUnauthorized, reason)
+			}
+		}
+		if err := provisioner.ProvisionHeartbeat(id, tmpl, ap.limit); err != nil {
+			s.rejectProvision(id, now, "auto-provisioning failed: "+err.Error(), "provision")
+			return fmt.Errorf("provision heartbeat %q: %w", id, err)
+		}
+		s.recordHeartbeatProvisioned(id, now, rule)
+		return nil
+	}
+	if len(ap.rules) > 0 {
+		s.rejectProvision(id, now, "no matching auto-provision rule", "no_rule")
+	}
+	return nil
+}
+
+// rejectProvision records a rejected provisioning ping in history. The
+// counter omits the heartbeat label: unknown ids are caller-chosen and would
+// mint unbounded series.
+func (s *Service) rejectProvision(id string, now time.Time, reason, label string) {
+	s.recordHeartbeatRejected(id, now, reason)
+	if s.metrics != nil {
+		s.metrics.IncHeartbeatRejected("", label)
+	}
+}
+
+func (s *Service) recordHeartbeatProvisioned(id string, now time.Time, rule config.AutoProvisionRule) {
+	if s.history == nil {
+		return
+	}
+	s.history.Add(history.Event{
+		Time:        now,
+		Type:        history.EventHeartbeatProvisioned.String(),
+		HeartbeatID: id,
+		Message:     "created from template " + rule.Template,
+		Fields: map[string]any{
+			"pattern":  rule.Pattern,
+			"template": rule.Template,
+		},
+	})
+}
@@ -4,16 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"sort"
-	"strings"
+	"sync"
 	"time"
 
 	kit "github.com/containeroo/notifykit/notify"
-	"github.com/containeroo/notifykit/targets/email"
-	"github.com/containeroo/notifykit/targets/webhook"
 
 	htypes "github.com/containeroo/heartbeats/internal/heartbeat/types"
 	"github.com/containeroo/heartbeats/internal/history"
 	"github.com/containeroo/heartbeats/internal/metrics"
+	appnotify "github.com/containeroo/heartbeats/internal/notify"
+	"github.com/containeroo/heartbeats/internal/payload"
 	"github.com/containeroo/heartbeats/internal/runner"
 )
 
@@ -29,11 +29,18 @@ type ReceiverStore interface {
 	Receivers() []*kit.Receiver
 }
 
+// ErrPayloadRejected marks a heartbeat whose payload failed its validation rules.
+var ErrPayloadRejected = errors.New("payload rejected")
+
 type Service struct {
 	manager   Store
 	receivers ReceiverStore
 	history   history.Recorder
 	metrics   *metrics.Registry
+
+	provisionMu   sync.RWMutex
+	provisioner   Provisioner
+	autoProvision autoProvision
 }
 
 // Status is the public heartbeat state payload.
@@ -81,7 +88,7 @@ func NewService(manager Store, receivers ReceiverStore, historyStore history.Rec
 }
 
 // Update records a new heartbeat payload for the given id.
-func (s *Service) Update(id string, payload string, now time.Time) error {
+func (s *Service) Update(id, contentType, payload string, now time.Time) error {
 	hb, ok := s.manager.Get(id)
 	if !ok {
 		// Unknown ids are deliberately not counted: the id becomes a metric
@@ -89,6 +96,22 @@ func (s *Service) Update(id string, payload string, now time.Time) error {
 		// unbounded label cardinality.
 		return fmt.Errorf("heartbeat %q not found", id)
 	}
+	samples, thresholdErr := hb.PayloadMetrics.Extract(payload)
+	s.setPayloadValues(id, samples)
+	failure := hb.PayloadRules.Check(payload)
+	if failure == nil {
+		failure = thresholdErr
+	}
+	if failure != nil {
+		// A rejected payload is still a received heartbeat: the job ran, but
+		// reported something we treat as a failure.
+		hb.State.RecordPayload(now, contentType, payload, failure.Error())
+		enqueued := hb.State.UpdateFailed(now, payload, failure.Error())
+		s.recordHeartbeatFailed(id, now, len(payload), failure.Error(), enqueued)
+		s.incHeartbeatReceived(id)
+		return fmt.Errorf("%w: %w", ErrPayloadRejected, failure)
+	}
+	hb.State.RecordPayload(now, contentType, payload, "")
 	if ok := hb.State.UpdateSeen(now, payload); !ok {
 		// The state (last seen, payload) was still updated; only the runner
 		// wake-up was dropped. The bump was received, so it counts.
@@ -109,6 +132,25 @@ func (s *Service) incHeartbeatReceived(id string) {
 	s.metrics.IncHeartbeatReceived(id)
 }
 
+// setPayloadValues exports extracted payload values when metrics are wired.
+func (s *Service) setPayloadValues(id string, samples []payload.Sample) {
+	if s.metrics == nil {
+		return
+	}
+	for _, sample := range samples {
+		s.metrics.SetPayloadValue(id, sample.Name, sample.Value)
+	}
+}
+
+// Payloads returns the stored payloads for a heartbeat, newest first.
+func (s *Service) Payloads(id string) ([]runner.PayloadRecord, error) {
+	hb, ok := s.manager.Get(id)
+	if !ok || hb.State == nil {
+		return nil, fmt.Errorf("heartbeat %q not found", id)
+	}
+	return hb.State.Payloads(), nil
+}
+
 // StatusByID returns the status for a heartbeat.
 func (s *Service) StatusByID(id string) (Status, error) {
 	hb, ok := s.manager.Get(id)
@@ -208,8 +250,8 @@ func (s *Service) ReceiverSummaries() []ReceiverSummary {
 			if target == nil {
 				continue
 			}
-			dest := targetDestination(target)
-			typeName := targetType(target)
+			typeName := appnotify.TargetType(target)
+			dest := appnotify.RedactDestination(typeName, appnotify.TargetDestination(target))
 			key := receiverStatusKey(rcv.Name, typeName, dest)
 			if _, ok := seen[key]; ok {
 				continue
@@ -240,7 +282,8 @@ func (s *Service) ReceiverSummaries() []ReceiverSummary {
 	return out
 }
 
-// ReceiverSummaryByKey returns a receiver summary by receiver/type/target.
+// ReceiverSummaryByKey returns a receiver summary by receiver/type/target,
+// where target is the redacted destination recorded in history.
 func (s *Service) ReceiverSummaryByKey(receiver, targetTypeName, target string) (ReceiverSummary, bool) {
 	if s == nil || s.receivers == nil {
 		return ReceiverSummary{}, false
@@ -255,8 +298,8 @@ func (s *Service) ReceiverSummaryByKey(receiver, targetTypeName, target string)
 			if t == nil {
 				continue
 			}
-			dest := targetDestination(t)
-			typeName := targetType(t)
+			typeName := appnotify.TargetType(t)
+			dest := appnotify.RedactDestination(typeName, appnotify.TargetDestination(t))
 			if typeName != targetTypeName || dest != target {
 				continue
 			}
@@ -331,30 +374,20 @@ func (s *Service) recordHeartbeatReceived(id string, now time.Time, payloadBytes
 	})
 }
 
-func targetDestination(target kit.Target) string {
-	switch t := target.(type) {
-	case *webhook.Target:
-		return t.URL
-	case *email.Target:
-		return strings.Join(t.To, ", ")
-	default:
-		return ""
-	}
-}
-
-func targetType(target kit.Target) string {
-	switch target.(type) {
-	case *webhook.Target:
-		return "webhook"
-	case *email.Target:
-		return "email"
-	default:
-		typed, ok := target.(interface{ Type() string })
-		if ok {
-			return typed.Type()
-		}
-		return "unknown"
+func (s *Service) recordHeartbeatFailed(id string, now time.Time, payloadBytes int, reason string, enqueued bool) {
+	if s.history == nil {
+		return
 	}
+	s.history.Add(history.Event{
+		Time:        now,
+		Type:        history.EventHeartbeatFailed.String(),
+		HeartbeatID: id,
+		Message:     reason,
+		Fields: map[string]any{
+			"payload_bytes": payloadBytes,
+			"enqueued":      enqueued,
+		},
+	})
 }
 
 func heartbeatHistoryIndex(recorder history.Recorder) map[string]bool {
@@ -391,7 +424,7 @@ func receiverHistoryIndex(recorder history.Recorder) map[string]receiverStatus {
 			continue
 		}
 		status := receiverStatus{time: ev.Time}
-		if ev.Type == history.EventNotificationFailed.String() {
+		if ev.Type == history.EventNotificationFailed.String() || ev.Type == history.EventNotificationRetried.String() {
 			status.err = ev.Message
 		}
 		out[key] = status
@@ -1,6 +1,10 @@
 package service
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -13,6 +17,7 @@ import (
 	"github.com/containeroo/heartbeats/internal/heartbeat/types"
 	"github.com/containeroo/heartbeats/internal/history"
 	"github.com/containeroo/heartbeats/internal/metrics"
+	"github.com/containeroo/heartbeats/internal/payload"
 	"github.com/containeroo/heartbeats/internal/runner"
 )
 
@@ -72,7 +77,7 @@ func newReceiverStore() ReceiverStore {
 			{
 				Name: "ops",
 				Targets: []kit.Target{
-					&webhook.Target{URL: "https://example.com"},
+					&webhook.Target{URL: "https://example.com/hooks/T0K3N"},
 				},
 			},
 		},
@@ -108,8 +113,8 @@ func TestServiceUpdate(t *testing.T) {
 	hb := newHeartbeat(t, "api", time.Second, 2*time.Second, runner.StageLate, time.Now())
 	store.s["api"] = hb
 
-	require.NoError(t, svc.Update("api", "payload", time.Now()))
-	err := svc.Update("api", "payload", time.Now())
+	require.NoError(t, svc.Update("api", "", "payload", time.Now()))
+	err := svc.Update("api", "", "payload", time.Now())
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "heartbeat mailbox full")
 
@@ -123,12 +128,31 @@ func TestServiceUpdate(t *testing.T) {
 		`heartbeats_heartbeat_received_total{heartbeat="api"} 2`)
 }
 
+func TestServicePayloads(t *testing.T) {
+	t.Parallel()
+
+	svc, store, _ := newTestService(t)
+	hb := newHeartbeat(t, "api", time.Second, 2*time.Second, runner.StageOK, time.Now())
+	store.s["api"] = hb
+
+	require.NoError(t, svc.Update("api", "application/json", `{"status":"ok"}`, time.Now()))
+
+	payloads, err := svc.Payloads("api")
+	require.NoError(t, err)
+	require.Len(t, payloads, 1)
+	require.Equal(t, "application/json", payloads[0].ContentType)
+	require.Equal(t, `{"status":"ok"}`, payloads[0].Body)
+
+	_, err = svc.Payloads("ghost")
+	require.Error(t, err)
+}
+
 func TestServiceUpdateUnknownID(t *testing.T) {
 	t.Parallel()
 
 	svc, _, _ := newTestService(t)
 
-	err := svc.Update("ghost", "payload", time.Now())
+	err := svc.Update("ghost", "", "payload", time.Now())
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "not found")
 
@@ -144,7 +168,61 @@ func TestServiceUpdateWithoutMetrics(t *testing.T) {
 	svc := NewService(store, newReceiverStore(), history.NewStore(10), nil)
 	store.s["api"] = newHeartbeat(t, "api", time.Second, 2*time.Second, runner.StageLate, time.Now())
 
-	require.NoError(t, svc.Update("api", "payload", time.Now()))
+	require.NoError(t, svc.Update("api", "", "payload", time.Now()))
+}
+
+func TestServiceUpdateRejectedPayload(t *testing.T) {
+	t.Parallel()
+
+	svc, store, hist := newTestService(t)
+	hb := newHeartbeat(t, "api", time.Second, 2*time.Second, runner.StageOK, time.Now())
+	rules, err := payload.NewRules(&config.PayloadRules{RequiredKeys: []string{"status"}})
+	require.NoError(t, err)
+	hb.PayloadRules = rules
+	store.s["api"] = hb
+
+	err = svc.Update("api", "", `{"result":"ok"}`, time.Now())
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrPayloadRejected))
+	require.Contains(t, err.Error(), `missing required key "status"`)
+
+	select {
+	case ev := <-hb.State.Mailbox():
+		require.Equal(t, runner.HeartbeatFail, ev)
+	default:
+		t.Fatal("expected failure event in mailbox")
+	}
+	require.Contains(t, hb.State.Snapshot().LastError, "status")
+
+	events := hist.List()
+	require.Len(t, events, 1)
+	require.Equal(t, history.EventHeartbeatFailed.String(), events[0].Type)
+	require.Contains(t, events[0].Message, `missing required key "status"`)
+
+	require.NoError(t, svc.Update("api", "", `{"status":"ok"}`, time.Now()))
+	require.Empty(t, hb.State.Snapshot().LastError)
+}
+
+func TestServiceUpdatePayloadMetrics(t *testing.T) {
+	t.Parallel()
+
+	svc, store, _ := newTestService(t)
+	hb := newHeartbeat(t, "backup", time.Second, 2*time.Second, runner.StageOK, time.Now())
+	minBytes := 1024.0
+	hb.PayloadMetrics = payload.NewExtractor([]config.PayloadMetric{
+		{Name: "bytes", Path: "bytes", Min: &minBytes},
+		{Name: "duration", Path: "duration"},
+	})
+	store.s["backup"] = hb
+
+	err := svc.Update("backup", "", `{"bytes":123,"duration":45}`, time.Now())
+	require.ErrorIs(t, err, ErrPayloadRejected)
+	require.Contains(t, err.Error(), "bytes 123 is below min 1024")
+	require.Equal(t, "bytes 123 is below min 1024", hb.State.Snapshot().LastError)
+
+	body := scrapeMetrics(t, svc)
+	require.Contains(t, body, `heartbeats_heartbeat_payload_value{heartbeat="backup",metric="bytes"} 123`)
+	require.Contains(t, body, `heartbeats_heartbeat_payload_value{heartbeat="backup",metric="duration"} 45`)
 }
 
 func TestServiceStatus(t *testing.T) {
@@ -187,7 +265,7 @@ func TestReceiverSummaries(t *testing.T) {
 		Receiver:   "ops",
 		TargetType: "webhook",
 		Fields: map[string]any{
-			"target": "https://example.com",
+			"target": "https://example.com/" + config.RedactedSecret,
 		},
 		Type:    history.EventNotificationFailed.String(),
 		Message: "boom",
@@ -198,10 +276,191 @@ func TestReceiverSummaries(t *testing.T) {
 	summaries := svc.ReceiverSummaries()
 	require.Len(t, summaries, 1)
 	require.Equal(t, "ops", summaries[0].ID)
-	require.Equal(t, "https://example.com", summaries[0].Destination)
+	require.Equal(t, "https://example.com/"+config.RedactedSecret, summaries[0].Destination, "webhook tokens are redacted")
 	require.Equal(t, "boom", summaries[0].LastErr)
 
-	receiver, ok := svc.ReceiverSummaryByKey("ops", "webhook", "https://example.com")
+	receiver, ok := svc.ReceiverSummaryByKey("ops", "webhook", "https://example.com/"+config.RedactedSecret)
 	require.True(t, ok)
 	require.Equal(t, "ops", receiver.ID)
+	require.Equal(t, "https://example.com/"+config.RedactedSecret, receiver.Destination)
+}
+
+func TestServiceVerifyPing(t *testing.T) {
+	t.Parallel()
+
+	body := `{"status":"ok"}`
+	mac := hmac.New(sha256.New, []byte("hmac-secret"))
+	mac.Write([]byte(body))
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name   string
+		ping   *config.PingConfig
+		creds  PingCredentials
+		reason string
+		label  string
+	}{
+		{name: "no ping config", creds: PingCredentials{}},
+		{name: "valid token", ping: &config.PingConfig{Token: "s3cret"}, creds: PingCredentials{Token: "s3cret"}},
+		{name: "missing token", ping: &config.PingConfig{Token: "s3cret"}, reason: "missing token", label: "token"},
+		{name: "invalid token", ping: &config.PingConfig{Token: "s3cret"}, creds: PingCredentials{Token: "guess"}, reason: "invalid token", label: "token"},
+		{name: "valid signature", ping: &config.PingConfig{HMACSecret: "hmac-secret"}, creds: PingCredentials{Signature: signature}},
+		{name: "missing signature", ping: &config.PingConfig{HMACSecret: "hmac-secret"}, reason: "missing signature", label: "signature"},
+		{name: "invalid signature", ping: &config.PingConfig{HMACSecret: "hmac-secret"}, creds: PingCredentials{Signature: "sha256=00"}, reason: "invalid signature", label: "signature"},
+		{name: "allowed client cert", ping: &config.PingConfig{ClientCertSubjects: []string{"backup"}}, creds: PingCredentials{ClientSubjects: []string{"CN=backup,O=ops", "backup"}}},
+		{name: "missing client cert", ping: &config.PingConfig{ClientCertSubjects: []string{"backup"}}, reason: "missing client certificate", label: "client_cert"},
+		{name: "other client cert", ping: &config.PingConfig{ClientCertSubjects: []string{"backup"}}, creds: PingCredentials{ClientSubjects: []string{"CN=web", "web"}}, reason: "client certificate not allowed", label: "client_cert"},
+		{name: "token and signature", ping: &config.PingConfig{Token: "s3cret", HMACSecret: "hmac-secret"}, creds: PingCredentials{Token: "s3cret", Signature: signature}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc, store, hist := newTestService(t)
+			hb := newHeartbeat(t, "api", time.Second, 2*time.Second, runner.StageOK, time.Now())
+			hb.Config.Ping = tc.ping
+			store.s["api"] = hb
+
+			err := svc.VerifyPing("api", tc.creds, body, time.Now())
+			if tc.reason == "" {
+				require.NoError(t, err)
+				require.Empty(t, hist.List())
+				return
+			}
+			require.ErrorIs(t, err, ErrPingUnauthorized)
+			require.Contains(t, err.Error(), tc.reason)
+
+			events := hist.List()
+			require.Len(t, events, 1)
+			require.Equal(t, history.EventHeartbeatRejected.String(), events[0].Type)
+			require.Equal(t, tc.reason, events[0].Message)
+			require.Contains(t, scrapeMetrics(t, svc),
+				`heartbeats_heartbeat_rejected_total{heartbeat="api",reason="`+tc.label+`"} 1`)
+		})
+	}
+
+	t.Run("unknown heartbeat", func(t *testing.T) {
+		t.Parallel()
+		svc, _, _ := newTestService(t)
+		err := svc.VerifyPing("ghost", PingCredentials{}, "", time.Now())
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrPingUnauthorized)
+	})
+}
+
+type fakeProvisioner struct {
+	t     *testing.T
+	store *fakeStore
+	ids   []string
+	err   error
+}
+
+func (f *fakeProvisioner) ProvisionHeartbeat(id string, hb config.HeartbeatConfig, limit int) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.ids = append(f.ids, id)
+	created := newHeartbeat(f.t, id, hb.Interval, hb.LateAfter, runner.StageOK, time.Time{})
+	created.Config = hb
+	f.store.s[id] = created
+	return nil
+}
+
+func TestServiceProvision(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		HeartbeatTemplates: map[string]config.HeartbeatConfig{
+			"cron":   {Interval: time.Hour, LateAfter: time.Minute, Receivers: []string{"ops"}},
+			"secure": {Interval: time.Hour, LateAfter: time.Minute, Receivers: []string{"ops"}, Ping: &config.PingConfig{Token: "s3cret"}},
+		},
+		AutoProvision: &config.AutoProvisionConfig{Rules: []config.AutoProvisionRule{
+			{Pattern: "secure-*", Template: "secure"},
+			{Pattern: "cron-*", Template: "cron"},
+		}},
+	}
+	setup := func(t *testing.T) (*Service, *fakeProvisioner, *history.Store) {
+		svc, store, hist := newTestService(t)
+		store.s["api"] = newHeartbeat(t, "api", time.Second, 2*time.Second, runner.StageOK, time.Now())
+		provisioner := &fakeProvisioner{t: t, store: store}
+		svc.SetProvisioner(provisioner)
+		svc.SetAutoProvision(cfg)
+		return svc, provisioner, hist
+	}
+
+	t.Run("creates matching ids from the template", func(t *testing.T) {
+		t.Parallel()
+		svc, provisioner, hist := setup(t)
+
+		require.NoError(t, svc.Provision("cron-nightly", PingCredentials{}, "", time.Now()))
+		require.Equal(t, []string{"cron-nightly"}, provisioner.ids)
+		require.NoError(t, svc.Update("cron-nightly", "", "", time.Now()))
+
+		events := hist.List()
+		require.NotEmpty(t, events)
+		require.Equal(t, history.EventHeartbeatProvisioned.String(), events[0].Type)
+		require.Equal(t, "created from template cron", events[0].Message)
+
+		require.NoError(t, svc.Provision("cron-nightly", PingCredentials{}, "", time.Now()))
+		require.Len(t, provisioner.ids, 1, "known ids are not provisioned again")
+	})
+
+	t.Run("ignores known and unmatched ids", func(t *testing.T) {
+		t.Parallel()
+		svc, provisioner, hist := setup(t)
+
+		require.NoError(t, svc.Provision("api", PingCredentials{}, "", time.Now()))
+		require.NoError(t, svc.Provision("backup", PingCredentials{}, "", time.Now()))
+		require.Empty(t, provisioner.ids)
+
+		events := hist.List()
+		require.Len(t, events, 1, "only the unmatched id is rejected")
+		require.Equal(t, history.EventHeartbeatRejected.String(), events[0].Type)
+		require.Equal(t, "backup", events[0].HeartbeatID)
+		require.Equal(t, "no matching auto-provision rule", events[0].Message)
+		require.Contains(t, scrapeMetrics(t, svc),
+			`heartbeats_heartbeat_rejected_total{heartbeat="",reason="no_rule"} 1`)
+		require.NotContains(t, scrapeMetrics(t, svc), `heartbeat="backup"`, "unknown ids are not labels")
+	})
+
+	t.Run("requires the template ping credentials", func(t *testing.T) {
+		t.Parallel()
+		svc, provisioner, hist := setup(t)
+
+		err := svc.Provision("secure-job", PingCredentials{Token: "guess"}, "", time.Now())
+		require.ErrorIs(t, err, ErrPingUnauthorized)
+		require.Empty(t, provisioner.ids)
+		events := hist.List()
+		require.Len(t, events, 1)
+		require.Equal(t, history.EventHeartbeatRejected.String(), events[0].Type)
+		require.Equal(t, "invalid token", events[0].Message)
+		require.Contains(t, scrapeMetrics(t, svc),
+			`heartbeats_heartbeat_rejected_total{heartbeat="",reason="token"} 1`)
+
+		require.NoError(t, svc.Provision("secure-job", PingCredentials{Token: "s3cret"}, "", time.Now()))
+		require.Equal(t, []string{"secure-job"}, provisioner.ids)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+		svc, provisioner, _ := setup(t)
+		svc.SetAutoProvision(&config.Config{})
+
+		require.NoError(t, svc.Provision("cron-nightly", PingCredentials{}, "", time.Now()))
+		require.Empty(t, provisioner.ids)
+	})
+
+	t.Run("store errors are returned", func(t *testing.T) {
+		t.Parallel()
+		svc, provisioner, hist := setup(t)
+		provisioner.err = errors.New("limit")
+
+		require.ErrorContains(t, svc.Provision("cron-nightly", PingCredentials{}, "", time.Now()), "limit")
+		events := hist.List()
+		require.Len(t, events, 1)
+		require.Equal(t, history.EventHeartbeatRejected.String(), events[0].Type)
+		require.Equal(t, "auto-provisioning failed: limit", events[0].Message)
+		require.Contains(t, scrapeMetrics(t, svc),
+			`heartbeats_heartbeat_rejected_total{heartbeat="",reason="provision"} 1`)
+	})
 }
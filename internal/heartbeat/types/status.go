@@ -9,6 +9,7 @@ const (
 	StatusLate
 	StatusMissing
 	StatusRecovered
+	StatusFailed
 )
 
 // String returns the status identifier.
@@ -22,6 +23,8 @@ func (s Status) String() string {
 		return "missing"
 	case StatusRecovered:
 		return "recovered"
+	case StatusFailed:
+		return "failed"
 	default:
 		return "unknown"
 	}
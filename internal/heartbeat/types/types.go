@@ -4,6 +4,7 @@ import (
 	kit "github.com/containeroo/notifykit/notify"
 
 	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/payload"
 	"github.com/containeroo/heartbeats/internal/runner"
 )
 
@@ -17,4 +18,6 @@ type Heartbeat struct {
 	State           *runner.State
 	AlertOnRecovery bool
 	AlertOnLate     bool
+	PayloadRules    *payload.Rules
+	PayloadMetrics  *payload.Extractor
 }
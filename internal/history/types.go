@@ -9,6 +9,11 @@ const (
 	EventHTTPAccess
 	EventNotificationDelivered
 	EventNotificationFailed
+	EventDigestSent
+	EventHeartbeatFailed
+	EventHeartbeatRejected
+	EventHeartbeatProvisioned
+	EventNotificationRetried
 )
 
 // String returns the history event identifier.
@@ -24,6 +29,16 @@ func (e EventType) String() string {
 		return "notification_delivered"
 	case EventNotificationFailed:
 		return "notification_failed"
+	case EventDigestSent:
+		return "digest_sent"
+	case EventHeartbeatFailed:
+		return "heartbeat_failed"
+	case EventHeartbeatRejected:
+		return "heartbeat_rejected"
+	case EventHeartbeatProvisioned:
+		return "heartbeat_provisioned"
+	case EventNotificationRetried:
+		return "notification_retried"
 	default:
 		return "unknown"
 	}
@@ -13,6 +13,11 @@ func TestEventTypeString(t *testing.T) {
 		EventHTTPAccess:            "http_access",
 		EventNotificationDelivered: "notification_delivered",
 		EventNotificationFailed:    "notification_failed",
+		EventDigestSent:            "digest_sent",
+		EventHeartbeatFailed:       "heartbeat_failed",
+		EventHeartbeatRejected:     "heartbeat_rejected",
+		EventHeartbeatProvisioned:  "heartbeat_provisioned",
+		EventNotificationRetried:   "notification_retried",
 		EventType(99):              "unknown",
 	}
 	for typ, expected := range cases {
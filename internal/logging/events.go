@@ -4,14 +4,20 @@ package logging
 type Event int
 
 const (
-	EventEncodeResponseFailed Event = iota
+	EventDigestFailed Event = iota
+	EventDigestSent
+	EventEncodeResponseFailed
+	EventFileWatchPolling
 	EventHeartbeatMailboxFull
 	EventHeartbeatMetadataMissing
 	EventHeartbeatStarted
 	EventHistoryDropped
+	EventNotificationDeadLettered
 	EventNotificationDelivered
 	EventNotificationDeliveryFailed
 	EventNotificationMissing
+	EventNotificationQueueSaveFailed
+	EventNotificationReplayed
 	EventNotificationTargetDelivered
 	EventNotificationTargetDispatch
 	EventNotificationTargetFailed
@@ -19,14 +25,22 @@ const (
 	EventReceiverMissing
 	EventRoutesMounted
 	EventStageTransition
+	EventTLSReloadFailed
+	EventTLSReloaded
 	EventWebhookResponse
 )
 
 // String returns the log event identifier.
 func (e Event) String() string {
 	switch e {
+	case EventDigestFailed:
+		return "digest_failed"
+	case EventDigestSent:
+		return "digest_sent"
 	case EventEncodeResponseFailed:
 		return "encode_response_failed"
+	case EventFileWatchPolling:
+		return "file_watch_polling"
 	case EventHeartbeatMailboxFull:
 		return "heartbeat_mailbox_full"
 	case EventHeartbeatMetadataMissing:
@@ -35,12 +49,18 @@ func (e Event) String() string {
 		return "heartbeat_started"
 	case EventHistoryDropped:
 		return "history_dropped"
+	case EventNotificationDeadLettered:
+		return "notification_dead_lettered"
 	case EventNotificationDelivered:
 		return "notification_delivered"
 	case EventNotificationDeliveryFailed:
 		return "notification_delivery_failed"
 	case EventNotificationMissing:
 		return "notification_missing"
+	case EventNotificationQueueSaveFailed:
+		return "notification_queue_save_failed"
+	case EventNotificationReplayed:
+		return "notification_replayed"
 	case EventNotificationTargetDelivered:
 		return "notification_target_delivered"
 	case EventNotificationTargetDispatch:
@@ -55,6 +75,10 @@ func (e Event) String() string {
 		return "routes_mounted"
 	case EventStageTransition:
 		return "stage_transition"
+	case EventTLSReloadFailed:
+		return "tls_reload_failed"
+	case EventTLSReloaded:
+		return "tls_reloaded"
 	case EventWebhookResponse:
 		return "webhook_response"
 	default:
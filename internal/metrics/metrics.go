@@ -2,6 +2,8 @@ package metrics
 
 import (
 	"net/http"
+	"slices"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -19,6 +21,7 @@ const (
 	HeartbeatLate      float64 = 1
 	HeartbeatMissing   float64 = 2
 	HeartbeatRecovered float64 = 3
+	HeartbeatFailed    float64 = 4
 	HeartbeatNever     float64 = -1
 )
 
@@ -27,7 +30,12 @@ type Registry struct {
 	registry           *prometheus.Registry
 	lastState          *prometheus.GaugeVec
 	receivedTotal      *prometheus.CounterVec
+	rejectedTotal      *prometheus.CounterVec
 	receiverLastStatus *prometheus.GaugeVec
+	payloadValue       *prometheus.GaugeVec
+
+	mu            sync.Mutex
+	payloadSeries map[string]map[string]struct{} // heartbeat -> exported payload metrics
 }
 
 // NewRegistry builds a new Prometheus metrics registry.
@@ -35,7 +43,7 @@ func NewRegistry() *Registry {
 	lastState := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "heartbeats_heartbeat_last_state",
-			Help: "Most recent state of each heartbeat (0 = ok, 1 = late, 2 = missing, 3 = recovered, -1 = never)",
+			Help: "Most recent state of each heartbeat (0 = ok, 1 = late, 2 = missing, 3 = recovered, 4 = failed, -1 = never)",
 		},
 		[]string{"heartbeat"},
 	)
@@ -46,6 +54,13 @@ func NewRegistry() *Registry {
 		},
 		[]string{"heartbeat"},
 	)
+	rejectedTotal := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "heartbeats_heartbeat_rejected_total",
+			Help: "Total number of heartbeat pings rejected for a missing or invalid token, signature, or client certificate, or by auto-provisioning",
+		},
+		[]string{"heartbeat", "reason"},
+	)
 	receiverLastStatus := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "heartbeats_receiver_last_status",
@@ -54,14 +69,25 @@ func NewRegistry() *Registry {
 		[]string{"receiver", "type", "target"},
 	)
 
+	payloadValue := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "heartbeats_heartbeat_payload_value",
+			Help: "Last numeric value extracted from a heartbeat payload",
+		},
+		[]string{"heartbeat", "metric"},
+	)
+
 	reg := prometheus.NewRegistry()
-	reg.MustRegister(lastState, receivedTotal, receiverLastStatus)
+	reg.MustRegister(lastState, receivedTotal, rejectedTotal, receiverLastStatus, payloadValue)
 
 	return &Registry{
 		registry:           reg,
 		lastState:          lastState,
 		receivedTotal:      receivedTotal,
+		rejectedTotal:      rejectedTotal,
 		receiverLastStatus: receiverLastStatus,
+		payloadValue:       payloadValue,
+		payloadSeries:      map[string]map[string]struct{}{},
 	}
 }
 
@@ -75,11 +101,46 @@ func (r *Registry) IncHeartbeatReceived(id string) {
 	r.receivedTotal.WithLabelValues(id).Inc()
 }
 
+// IncHeartbeatRejected increments the rejected ping counter for a heartbeat.
+func (r *Registry) IncHeartbeatRejected(id, reason string) {
+	r.rejectedTotal.WithLabelValues(id, reason).Inc()
+}
+
 // SetReceiverStatus sets the receiver status gauge.
 func (r *Registry) SetReceiverStatus(receiver, typ, target string, status float64) {
 	r.receiverLastStatus.WithLabelValues(receiver, typ, target).Set(status)
 }
 
+// SetPayloadValue sets the gauge for a value extracted from a heartbeat payload.
+func (r *Registry) SetPayloadValue(id, metric string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.payloadSeries[id] == nil {
+		r.payloadSeries[id] = map[string]struct{}{}
+	}
+	r.payloadSeries[id][metric] = struct{}{}
+	r.payloadValue.WithLabelValues(id, metric).Set(value)
+}
+
+// PrunePayloadValues deletes payload value series of heartbeats and metrics
+// that are no longer configured. keep maps heartbeat IDs to metric names.
+func (r *Registry) PrunePayloadValues(keep map[string][]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, series := range r.payloadSeries {
+		for metric := range series {
+			if slices.Contains(keep[id], metric) {
+				continue
+			}
+			r.payloadValue.DeleteLabelValues(id, metric)
+			delete(series, metric)
+		}
+		if len(series) == 0 {
+			delete(r.payloadSeries, id)
+		}
+	}
+}
+
 // Metrics returns the Prometheus metrics handler.
 func (r *Registry) Metrics() http.Handler {
 	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{})
@@ -95,6 +156,8 @@ func heartbeatStateValue(state string) float64 {
 		return HeartbeatMissing
 	case "recovered":
 		return HeartbeatRecovered
+	case "failed":
+		return HeartbeatFailed
 	case "never":
 		return HeartbeatNever
 	default:
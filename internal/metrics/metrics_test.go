@@ -15,6 +15,7 @@ func TestHeartbeatStateValue(t *testing.T) {
 		"late":      HeartbeatLate,
 		"missing":   HeartbeatMissing,
 		"recovered": HeartbeatRecovered,
+		"failed":    HeartbeatFailed,
 		"never":     HeartbeatNever,
 		"unknown":   HeartbeatNever,
 	}
@@ -40,6 +41,11 @@ func TestRegistryMetrics(t *testing.T) {
 		reg.IncHeartbeatReceived("api")
 		require.Equal(t, float64(1), testutil.ToFloat64(reg.receivedTotal.WithLabelValues("api")))
 	})
+	t.Run("SetPayloadValue", func(t *testing.T) {
+		t.Parallel()
+		reg.SetPayloadValue("backup", "bytes", 1024)
+		require.Equal(t, float64(1024), testutil.ToFloat64(reg.payloadValue.WithLabelValues("backup", "bytes")))
+	})
 	t.Run("SetReceiverStatus", func(t *testing.T) {
 		t.Parallel()
 		reg.SetReceiverStatus("ops", "webhook", "https://example", ERROR)
@@ -47,6 +53,23 @@ func TestRegistryMetrics(t *testing.T) {
 	})
 }
 
+func TestPrunePayloadValues(t *testing.T) {
+	t.Parallel()
+	reg := NewRegistry()
+	reg.SetPayloadValue("backup", "bytes", 1024)
+	reg.SetPayloadValue("backup", "files", 12)
+	reg.SetPayloadValue("removed", "bytes", 1)
+
+	reg.PrunePayloadValues(map[string][]string{"backup": {"bytes"}})
+
+	require.Equal(t, 1, testutil.CollectAndCount(reg.payloadValue))
+	require.Equal(t, float64(1024), testutil.ToFloat64(reg.payloadValue.WithLabelValues("backup", "bytes")))
+
+	reg.SetPayloadValue("removed", "bytes", 2)
+	reg.PrunePayloadValues(nil)
+	require.Equal(t, 0, testutil.CollectAndCount(reg.payloadValue))
+}
+
 func TestRegistryMetricsHandler(t *testing.T) {
 	reg := NewRegistry()
 	reg.SetHeartbeatState("api", "ok")
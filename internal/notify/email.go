@@ -0,0 +1,328 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	kit "github.com/containeroo/notifykit/notify"
+)
+
+const (
+	defaultEmailTimeout = 30 * time.Second
+	statusImageCID      = "status"
+	statusImageSize     = 32
+)
+
+// emailTarget delivers a notification over SMTP as a multipart/alternative
+// message with a plain-text and an HTML body. With status images enabled,
+// the HTML body is wrapped in multipart/related together with an inline PNG
+// that templates reference as .StatusImage.
+type emailTarget struct {
+	Name          string
+	Host          string
+	Port          int
+	User          string
+	Pass          string
+	From          string
+	To            []string
+	Headers       map[string]string
+	StartTLS      bool // Upgrade the connection with STARTTLS; the server must offer it.
+	SSL           bool // Connect with implicit TLS.
+	SkipTLSVerify bool
+	StatusImages  bool
+	HTMLTmpl      *template.Template
+	TextTmpl      *template.Template
+	SubjectTmpl   *template.Template
+}
+
+// Type reports the target type.
+func (t *emailTarget) Type() string { return "email" }
+
+// Validate renders the subject and both bodies for p without sending.
+func (t *emailTarget) Validate(p kit.Payload) error {
+	_, err := t.message(p)
+	return err
+}
+
+// Send renders p and delivers it to all recipients.
+func (t *emailTarget) Send(ctx context.Context, p kit.Payload) error {
+	msg, err := t.message(p)
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultEmailTimeout)
+		defer cancel()
+	}
+	return t.send(ctx, msg)
+}
+
+// message renders the complete message for p.
+func (t *emailTarget) message(p kit.Payload) ([]byte, error) {
+	event, ok := p.Notification.(*Event)
+	if !ok {
+		return nil, fmt.Errorf("unsupported notification %T", p.Notification)
+	}
+
+	data := NewData(*event, p.Receiver, p.CustomData, "")
+	subject, err := renderTemplate(t.SubjectTmpl, data)
+	if err != nil {
+		return nil, fmt.Errorf("render subject: %w", err)
+	}
+	data.Subject = strings.TrimSpace(subject)
+	if t.StatusImages {
+		data.StatusImage = "cid:" + statusImageCID
+	}
+	htmlBody, err := renderTemplate(t.HTMLTmpl, data)
+	if err != nil {
+		return nil, fmt.Errorf("render html body: %w", err)
+	}
+	textBody, err := renderTemplate(t.TextTmpl, data)
+	if err != nil {
+		return nil, fmt.Errorf("render text body: %w", err)
+	}
+
+	var body bytes.Buffer
+	alt := multipart.NewWriter(&body)
+	if err := writeTextPart(alt, "text/plain", textBody); err != nil {
+		return nil, err
+	}
+	if t.StatusImages {
+		err = writeRelatedPart(alt, htmlBody, event.StatusValue)
+	} else {
+		err = writeTextPart(alt, "text/html", htmlBody)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := alt.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	writeHeader(&msg, "From", t.From)
+	writeHeader(&msg, "To", strings.Join(t.To, ", "))
+	writeHeader(&msg, "Subject", mime.QEncoding.Encode("utf-8", data.Subject))
+	writeHeader(&msg, "Date", event.Time.Format(time.RFC1123Z))
+	writeHeader(&msg, "Message-ID", messageID(event.ID(), t.From))
+	for _, key := range slices.Sorted(maps.Keys(t.Headers)) {
+		writeHeader(&msg, key, t.Headers[key])
+	}
+	writeHeader(&msg, "MIME-Version", "1.0")
+	writeHeader(&msg, "Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": alt.Boundary()}))
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// send delivers msg over a new SMTP connection.
+func (t *emailTarget) send(ctx context.Context, msg []byte) error {
+	addr := net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+	tlsConfig := &tls.Config{ServerName: t.Host, InsecureSkipVerify: t.SkipTLSVerify} // nolint:gosec
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if t.SSL {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, t.Host)
+	if err != nil {
+		conn.Close() // nolint:errcheck
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer client.Close() // nolint:errcheck
+
+	if t.StartTLS && !t.SSL {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("starttls: not supported by %s", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if t.User != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.User, t.Pass, t.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(t.From); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	for _, to := range t.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp rcpt to %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	return client.Quit()
+}
+
+// renderTemplate executes tmpl with data.
+func renderTemplate(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// messageID returns a unique Message-ID of the form
+// <eventID.random@domain>, taking the domain from the sender address.
+func messageID(eventID, from string) string {
+	domain := "heartbeats"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if _, host, ok := strings.Cut(addr.Address, "@"); ok && host != "" {
+			domain = host
+		}
+	}
+	random := make([]byte, 8)
+	_, _ = rand.Read(random) // crypto/rand never returns an error
+	if eventID == "" {
+		return "<" + hex.EncodeToString(random) + "@" + domain + ">"
+	}
+	return "<" + eventID + "." + hex.EncodeToString(random) + "@" + domain + ">"
+}
+
+// writeHeader writes one message header line.
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(textproto.CanonicalMIMEHeaderKey(key) + ": " + value + "\r\n")
+}
+
+// writeTextPart adds a quoted-printable UTF-8 part of the given media type.
+func writeTextPart(w *multipart.Writer, mediaType, text string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mediaType + "; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(text)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeRelatedPart adds the HTML body and the inline status image as a
+// multipart/related part.
+func writeRelatedPart(w *multipart.Writer, html, status string) error {
+	var body bytes.Buffer
+	related := multipart.NewWriter(&body)
+	if err := writeTextPart(related, "text/html", html); err != nil {
+		return err
+	}
+	img, err := related.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"image/png"},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Id":                {"<" + statusImageCID + ">"},
+		"Content-Disposition":       {`inline; filename="status.png"`},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := img.Write(wrapBase64(statusImage(status))); err != nil {
+		return err
+	}
+	if err := related.Close(); err != nil {
+		return err
+	}
+
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {mime.FormatMediaType("multipart/related", map[string]string{
+			"boundary": related.Boundary(),
+			"type":     "text/html",
+		})},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(body.Bytes())
+	return err
+}
+
+// wrapBase64 encodes data as base64 in lines of 76 characters.
+func wrapBase64(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var buf bytes.Buffer
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
+
+// statusImage draws a filled circle in the color of status as PNG.
+func statusImage(status string) []byte {
+	fill := statusColor(status)
+	img := image.NewNRGBA(image.Rect(0, 0, statusImageSize, statusImageSize))
+	center := float64(statusImageSize-1) / 2
+	for y := range statusImageSize {
+		for x := range statusImageSize {
+			dx, dy := float64(x)-center, float64(y)-center
+			if dx*dx+dy*dy <= center*center {
+				img.Set(x, y, fill)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img) // encoding to memory cannot fail
+	return buf.Bytes()
+}
+
+// statusColor returns the image color of a heartbeat status.
+func statusColor(status string) color.NRGBA {
+	switch status {
+	case "ok", "recovered":
+		return color.NRGBA{R: 0x2e, G: 0xa0, B: 0x43, A: 0xff}
+	case "late":
+		return color.NRGBA{R: 0xe3, G: 0xa0, B: 0x08, A: 0xff}
+	case "missing", "failed":
+		return color.NRGBA{R: 0xd7, G: 0x3a, B: 0x49, A: 0xff}
+	default:
+		return color.NRGBA{R: 0x8b, G: 0x94, B: 0x9e, A: 0xff}
+	}
+}
@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	kit "github.com/containeroo/notifykit/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpServer accepts one message without authentication and sends its DATA
+// to messages. It advertises STARTTLS but cannot complete a handshake, so
+// clients must not upgrade unless asked to.
+func smtpServer(t *testing.T) (string, int, <-chan []byte) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() }) // nolint:errcheck
+
+	messages := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close() // nolint:errcheck
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO":
+				reply("250-localhost")
+				reply("250 STARTTLS")
+			case "DATA":
+				reply("354 go ahead")
+				var data bytes.Buffer
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(line, "."))
+				}
+				messages <- data.Bytes()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return host, portNum, messages
+}
+
+// parts returns the media types and bodies of a multipart body.
+func parts(t *testing.T, contentType string, body io.Reader) map[string]string {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(mediaType, "multipart/"), mediaType)
+
+	out := map[string]string{}
+	r := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			return out
+		}
+		require.NoError(t, err)
+		partType := part.Header.Get("Content-Type")
+		if strings.HasPrefix(partType, "multipart/") {
+			for k, v := range parts(t, partType, part) {
+				out[k] = v
+			}
+			continue
+		}
+		data, err := io.ReadAll(part)
+		require.NoError(t, err)
+		mediaType, _, err := mime.ParseMediaType(partType)
+		require.NoError(t, err)
+		out[mediaType] = string(data)
+	}
+}
+
+func TestEmailTarget(t *testing.T) {
+	t.Parallel()
+
+	newTarget := func(t *testing.T, images bool) (*emailTarget, <-chan []byte) {
+		host, port, messages := smtpServer(t)
+		return &emailTarget{
+			Host:         host,
+			Port:         port,
+			From:         "heartbeats@example.com",
+			To:           []string{"ops@example.com"},
+			Headers:      map[string]string{"X-Team": "ops"},
+			StatusImages: images,
+			HTMLTmpl:     template.Must(template.New("html").Parse(`<p>{{ with .StatusImage }}<img src="{{ . }}">{{ end }}{{ .Title }}</p>`)),
+			TextTmpl:     template.Must(template.New("text").Parse(`{{ .Title }} is {{ .Status }}`)),
+			SubjectTmpl:  template.Must(template.New("subject").Parse(`[{{ .Title }}] {{ .Status }}`)),
+		}, messages
+	}
+	payload := kit.Payload{
+		Notification: NewEvent("api", "API", "late", "", 0, time.Now(), time.Minute, time.Minute, nil),
+		Receiver:     "ops",
+	}
+
+	t.Run("sends html and text alternatives", func(t *testing.T) {
+		t.Parallel()
+		target, messages := newTarget(t, false)
+		require.NoError(t, target.Send(context.Background(), payload))
+
+		msg, err := mail.ReadMessage(bytes.NewReader(<-messages))
+		require.NoError(t, err)
+		assert.Equal(t, "[API] late", msg.Header.Get("Subject"))
+		assert.Equal(t, "ops", msg.Header.Get("X-Team"))
+		assert.Regexp(t, `^<`+regexp.QuoteMeta(payload.Notification.ID())+`\.[0-9a-f]{16}@example\.com>$`, msg.Header.Get("Message-ID"))
+		assert.True(t, strings.HasPrefix(msg.Header.Get("Content-Type"), "multipart/alternative"))
+
+		bodies := parts(t, msg.Header.Get("Content-Type"), msg.Body)
+		assert.Equal(t, "API is late", bodies["text/plain"])
+		assert.Equal(t, "<p>API</p>", bodies["text/html"])
+		assert.NotContains(t, bodies, "image/png")
+	})
+
+	t.Run("embeds the status image", func(t *testing.T) {
+		t.Parallel()
+		target, messages := newTarget(t, true)
+		require.NoError(t, target.Send(context.Background(), payload))
+
+		msg, err := mail.ReadMessage(bytes.NewReader(<-messages))
+		require.NoError(t, err)
+		bodies := parts(t, msg.Header.Get("Content-Type"), msg.Body)
+		assert.Equal(t, `<p><img src="cid:status">API</p>`, bodies["text/html"])
+		assert.NotEmpty(t, bodies["image/png"])
+	})
+
+	t.Run("upgrades with STARTTLS only when enabled", func(t *testing.T) {
+		t.Parallel()
+		target, _ := newTarget(t, false)
+		target.StartTLS = true
+		err := target.Send(context.Background(), payload)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "starttls")
+	})
+
+	t.Run("reports template errors on validation", func(t *testing.T) {
+		t.Parallel()
+		target, _ := newTarget(t, false)
+		target.TextTmpl = template.Must(template.New("text").Parse(`{{ .Missing }}`))
+		err := target.Validate(payload)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "render text body")
+	})
+}
@@ -12,16 +12,17 @@ var eventCounter uint64
 
 // Event wraps a heartbeat lifecycle event as a notifykit notification.
 type Event struct {
-	IDValue      string
-	Heartbeat    string
-	TitleValue   string
-	StatusValue  string
-	Body         string
-	SinceValue   time.Duration
-	Time         time.Time
-	Interval     time.Duration
-	LateAfter    time.Duration
-	ReceiverList []kit.ReceiverID
+	IDValue      string           `json:"id"`
+	Heartbeat    string           `json:"heartbeat"`
+	TitleValue   string           `json:"title"`
+	StatusValue  string           `json:"status"`
+	Body         string           `json:"body,omitempty"`
+	Reason       string           `json:"reason,omitempty"`
+	SinceValue   time.Duration    `json:"since"`
+	Time         time.Time        `json:"time"`
+	Interval     time.Duration    `json:"interval"`
+	LateAfter    time.Duration    `json:"lateAfter"`
+	ReceiverList []kit.ReceiverID `json:"receivers"`
 }
 
 // NewEvent constructs an Event notification.
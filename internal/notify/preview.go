@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/containeroo/heartbeats/internal/config"
+)
+
+// ErrUnknownHeartbeat marks a preview for a heartbeat missing from the config.
+var ErrUnknownHeartbeat = errors.New("unknown heartbeat")
+
+// Sample values used when a preview is not bound to a heartbeat.
+const (
+	sampleHeartbeat = "example"
+	sampleTitle     = "Example"
+	samplePayload   = `{"job":"backup","status":"ok"}`
+	sampleInterval  = 5 * time.Minute
+	sampleLateAfter = time.Minute
+)
+
+// RenderRequest selects a template and the data it is rendered with.
+type RenderRequest struct {
+	Template  string        `json:"template,omitempty"`  // Template text; takes precedence over the receiver's template.
+	Receiver  string        `json:"receiver,omitempty"`  // Receiver whose configured template and vars are used.
+	Target    string        `json:"target,omitempty"`    // webhook or email; defaults to the first the receiver has.
+	Index     int           `json:"index,omitempty"`     // Target index within the receiver.
+	Subject   bool          `json:"subject,omitempty"`   // Render the subject template instead of the body.
+	Text      bool          `json:"text,omitempty"`      // Render the plain-text body of an email target instead of the HTML body.
+	Heartbeat string        `json:"heartbeat,omitempty"` // Heartbeat providing title, intervals and templates; empty = sample data.
+	Status    string        `json:"status,omitempty"`    // Status to render (default late).
+	Payload   string        `json:"payload,omitempty"`   // Payload exposed as .Payload; empty = sample payload.
+	Since     time.Duration `json:"-"`                   // Time since the last ping.
+}
+
+// RenderError is a template that failed to parse or execute.
+type RenderError struct {
+	Stage string // parse or execute.
+	Err   error  // text/template error with template name, line and column.
+}
+
+// Error implements error.
+func (e *RenderError) Error() string {
+	return e.Stage + ": " + e.Err.Error()
+}
+
+// Unwrap returns the template error.
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+// Render renders a template with the notification data a receiver would get
+// for the requested heartbeat and status. Receiver templates are resolved
+// like on delivery, including heartbeat defaults and built-in shortcuts, and
+// the body sees the rendered subject as .Subject.
+func Render(templateFS fs.FS, cfg *config.Config, req RenderRequest) (string, error) {
+	heartbeatID, hb := sampleHeartbeat, config.HeartbeatConfig{
+		Title:     sampleTitle,
+		Interval:  sampleInterval,
+		LateAfter: sampleLateAfter,
+	}
+	if req.Heartbeat != "" {
+		var ok bool
+		if hb, ok = cfg.Heartbeats[req.Heartbeat]; !ok {
+			return "", fmt.Errorf("%w %q", ErrUnknownHeartbeat, req.Heartbeat)
+		}
+		heartbeatID = req.Heartbeat
+	}
+
+	var (
+		receiverCfg config.ReceiverConfig
+		body        = templateSource{name: "template", text: req.Template}
+		subject     = templateSource{name: "subject", text: subjectTemplate(hb.SubjectTmpl, "")}
+	)
+	if req.Receiver != "" {
+		var ok bool
+		if receiverCfg, ok = cfg.Receivers[req.Receiver]; !ok {
+			return "", fmt.Errorf("%w %q", ErrUnknownReceiver, req.Receiver)
+		}
+		var err error
+		if body, subject, err = receiverTemplates(templateFS, hb, receiverCfg, req.Target, req.Index, req.Text); err != nil {
+			return "", err
+		}
+		if req.Template != "" {
+			body = templateSource{name: "template", text: req.Template}
+		}
+	}
+	if req.Subject && req.Template != "" {
+		subject = templateSource{name: "subject", text: req.Template}
+	}
+	if !req.Subject && body.text == "" {
+		return "", errors.New("template or receiver is required")
+	}
+
+	event := NewEvent(
+		heartbeatID,
+		firstNonEmpty(hb.Title, heartbeatID),
+		firstNonEmpty(req.Status, "late"),
+		firstNonEmpty(req.Payload, samplePayload),
+		req.Since,
+		time.Now(),
+		hb.Interval,
+		hb.LateAfter,
+		nil,
+	)
+	// Vars are write-only secrets like in the receiver API, so previews only
+	// see their redacted values.
+	vars := varsFromConfig(receiverCfg.Redacted().Vars)
+
+	renderedSubject, err := execute(templateFS, subject, event.Data(req.Receiver, vars, ""))
+	if err != nil || req.Subject {
+		return renderedSubject, err
+	}
+	return execute(templateFS, body, event.Data(req.Receiver, vars, renderedSubject))
+}
+
+// templateSource is a named template text.
+type templateSource struct {
+	name string
+	text string
+}
+
+// receiverTemplates returns the body and subject templates of a receiver target.
+func receiverTemplates(
+	templateFS fs.FS,
+	hb config.HeartbeatConfig,
+	rcv config.ReceiverConfig,
+	target string,
+	idx int,
+	text bool,
+) (templateSource, templateSource, error) {
+	if target == "" && len(rcv.Webhooks) == 0 {
+		target = "email"
+	}
+	switch target {
+	case "", "webhook":
+		if idx < 0 || idx >= len(rcv.Webhooks) {
+			return templateSource{}, templateSource{}, fmt.Errorf("receiver has no webhook[%d]", idx)
+		}
+		if text {
+			return templateSource{}, templateSource{}, errors.New("plain-text bodies exist only for email targets")
+		}
+		cfg := rcv.Webhooks[idx]
+		body, err := readTemplate(templateFS, webhookTemplateSource(firstNonEmpty(cfg.Template, hb.WebhookTemplate)))
+		subject := templateSource{name: "webhook-subject", text: subjectTemplate(hb.SubjectTmpl, cfg.SubjectTmpl)}
+		return body, subject, err
+	case "email":
+		if idx < 0 || idx >= len(rcv.Emails) {
+			return templateSource{}, templateSource{}, fmt.Errorf("receiver has no email[%d]", idx)
+		}
+		cfg := rcv.Emails[idx]
+		source := emailTemplateSource(firstNonEmpty(cfg.Template, hb.EmailTemplate))
+		if text {
+			source = emailTextTemplateSource(firstNonEmpty(cfg.TextTemplate, hb.EmailTextTemplate))
+		}
+		body, err := readTemplate(templateFS, source)
+		subject := templateSource{name: "email-subject", text: subjectTemplate(hb.SubjectTmpl, cfg.SubjectTmpl)}
+		return body, subject, err
+	default:
+		return templateSource{}, templateSource{}, fmt.Errorf("target %q must be webhook or email", target)
+	}
+}
+
+// readTemplate reads a template source like delivery does.
+func readTemplate(templateFS fs.FS, source string) (templateSource, error) {
+	text, err := ReadTemplate(templateFS, source)
+	if err != nil {
+		return templateSource{}, err
+	}
+	return templateSource{name: templateName(source), text: text}, nil
+}
+
+// execute parses and renders a template with the same helpers and partials
+// as delivery.
+func execute(templateFS fs.FS, src templateSource, data any) (string, error) {
+	tmpl, err := parseTemplate(templateFS, src.name, src.text)
+	if err != nil {
+		return "", &RenderError{Stage: "parse", Err: err}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", &RenderError{Stage: "execute", Err: err}
+	}
+	return buf.String(), nil
+}
@@ -1,6 +1,7 @@
 package notify
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
@@ -9,12 +10,12 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/containeroo/heartbeats/internal/config"
 
 	kit "github.com/containeroo/notifykit/notify"
-	"github.com/containeroo/notifykit/targets/email"
 	"github.com/containeroo/notifykit/targets/webhook"
 	"github.com/containeroo/notifykit/templates"
 )
@@ -34,10 +35,24 @@ var templateFuncs = []templates.Option{
 	templates.WithFunc("join", join),
 }
 
+// FuncMap returns the heartbeat-specific template helpers for templates
+// rendered outside notifykit targets.
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"formatDuration": formatDuration,
+		"isRecent":       isRecent,
+		"ago":            ago,
+		"join":           join,
+		"upper":          strings.ToUpper,
+		"lower":          strings.ToLower,
+	}
+}
+
 // ReceiverRoutes maps heartbeat IDs to explicit notifykit receiver IDs.
 type ReceiverRoutes map[string][]kit.ReceiverID
 
 // ReceiversFromConfig builds notifykit receivers and heartbeat routes from config.
+// Every invalid receiver reference and template is reported, joined into one error.
 func ReceiversFromConfig(templateFS fs.FS, cfg *config.Config, logger *slog.Logger) (kit.Receivers, ReceiverRoutes, error) {
 	if cfg == nil {
 		return nil, nil, fmt.Errorf("config is nil")
@@ -45,42 +60,80 @@ func ReceiversFromConfig(templateFS fs.FS, cfg *config.Config, logger *slog.Logg
 
 	receivers := make(kit.Receivers)
 	routes := make(ReceiverRoutes, len(cfg.Heartbeats))
+	var errs []error
 
 	for _, heartbeatID := range sortedHeartbeatIDs(cfg.Heartbeats) {
 		hb := cfg.Heartbeats[heartbeatID]
 		for _, receiverName := range hb.Receivers {
 			receiverCfg, ok := cfg.Receivers[receiverName]
 			if !ok {
-				return nil, nil, fmt.Errorf("heartbeat %q references unknown receiver %q", heartbeatID, receiverName)
+				errs = append(errs, fmt.Errorf("heartbeat %q references unknown receiver %q", heartbeatID, receiverName))
+				continue
 			}
 
-			receiver, err := receiverFromConfig(templateFS, heartbeatID, hb, receiverName, receiverCfg, logger)
+			receiver, err := receiverFromConfig(templateFS, receiverID(heartbeatID, receiverName), hb, receiverName, receiverCfg, logger)
 			if err != nil {
-				return nil, nil, fmt.Errorf("heartbeat %q receiver %q: %w", heartbeatID, receiverName, err)
+				errs = append(errs, fmt.Errorf("heartbeat %q receiver %q: %w", heartbeatID, receiverName, err))
+				continue
 			}
 			receivers[receiver.ID] = receiver
 			routes[heartbeatID] = append(routes[heartbeatID], receiver.ID)
 		}
 	}
 
+	for _, digestName := range sortedKeys(cfg.Digests) {
+		digest := cfg.Digests[digestName]
+		hb := digestHeartbeatConfig(digestName, digest)
+		for _, receiverName := range digest.Receivers {
+			receiverCfg, ok := cfg.Receivers[receiverName]
+			if !ok {
+				errs = append(errs, fmt.Errorf("digest %q references unknown receiver %q", digestName, receiverName))
+				continue
+			}
+
+			receiver, err := receiverFromConfig(templateFS, DigestReceiverID(digestName, receiverName), hb, receiverName, receiverCfg, logger)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("digest %q receiver %q: %w", digestName, receiverName, err))
+				continue
+			}
+			receivers[receiver.ID] = receiver
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
 	return receivers, routes, nil
 }
 
-// ReplaceReceivers swaps the contents of dst with src while keeping the same map instance.
-func ReplaceReceivers(dst, src kit.Receivers) {
-	for id := range dst {
-		delete(dst, id)
+// DigestReceiverIDs returns the notifykit receiver IDs for a digest.
+func DigestReceiverIDs(digestName string, receiverNames []string) []kit.ReceiverID {
+	out := make([]kit.ReceiverID, 0, len(receiverNames))
+	for _, receiverName := range receiverNames {
+		out = append(out, DigestReceiverID(digestName, receiverName))
 	}
-	maps.Copy(dst, src)
+	return out
 }
 
-// ReplaceRoutes swaps the contents of dst with src while keeping the same map instance.
-func ReplaceRoutes(dst, src ReceiverRoutes) {
-	for id := range dst {
-		delete(dst, id)
-	}
-	for id, receivers := range src {
-		dst[id] = append([]kit.ReceiverID(nil), receivers...)
+// DigestReceiverID returns the notifykit receiver ID for a digest receiver.
+func DigestReceiverID(digestName, receiverName string) kit.ReceiverID {
+	return kit.ReceiverID("digest." + digestName + ".receiver." + receiverName)
+}
+
+// digestHeartbeatConfig maps digest template settings onto the heartbeat
+// template fields consumed by the target builders.
+func digestHeartbeatConfig(name string, digest config.DigestConfig) config.HeartbeatConfig {
+	title := strings.TrimSpace(digest.Title)
+	if title == "" {
+		title = name
+	}
+	return config.HeartbeatConfig{
+		Title:             title,
+		SubjectTmpl:       digest.SubjectTmpl,
+		WebhookTemplate:   digest.WebhookTemplate,
+		EmailTemplate:     digest.EmailTemplate,
+		EmailTextTemplate: digest.EmailTextTemplate,
+		Receivers:         digest.Receivers,
 	}
 }
 
@@ -94,7 +147,7 @@ func (r ReceiverRoutes) ReceiverIDs(heartbeatID string) []kit.ReceiverID {
 
 func receiverFromConfig(
 	templateFS fs.FS,
-	heartbeatID string,
+	id kit.ReceiverID,
 	hb config.HeartbeatConfig,
 	receiverName string,
 	receiverCfg config.ReceiverConfig,
@@ -119,7 +172,7 @@ func receiverFromConfig(
 	}
 
 	return &kit.Receiver{
-		ID:         receiverID(heartbeatID, receiverName),
+		ID:         id,
 		Name:       receiverName,
 		Targets:    targets,
 		CustomData: varsFromConfig(receiverCfg.Vars),
@@ -139,20 +192,12 @@ func webhookTargetsFromConfig(
 
 	out := make([]kit.Target, 0, len(receiverCfg.Webhooks))
 	for idx, cfg := range receiverCfg.Webhooks {
-		tmpl, err := templates.LoadSource(
-			templateFS,
-			webhookTemplateSource(firstNonEmpty(cfg.Template, hb.WebhookTemplate)),
-			templateFuncs...,
-		)
+		tmpl, err := loadTemplate(templateFS, webhookTemplateSource(firstNonEmpty(cfg.Template, hb.WebhookTemplate)))
 		if err != nil {
 			return nil, fmt.Errorf("load webhook[%d] template: %w", idx, err)
 		}
 
-		subjectTmpl, err := templates.ParseStringTemplate(
-			"webhook-subject",
-			subjectTemplate(hb.SubjectTmpl, cfg.SubjectTmpl),
-			templateFuncs...,
-		)
+		subjectTmpl, err := parseTemplate(templateFS, "webhook-subject", subjectTemplate(hb.SubjectTmpl, cfg.SubjectTmpl))
 		if err != nil {
 			return nil, fmt.Errorf("parse webhook[%d] subject template: %w", idx, err)
 		}
@@ -194,7 +239,7 @@ func webhookTargetsFromConfig(
 				LogResponse:       logResponse,
 				ResponseBodyLimit: bodyLimit,
 			},
-			webhook.WithClient(webhook.NewClient(timeout, clientOptions...)),
+			webhook.WithClient(RecordResponses(SignRequests(webhook.NewClient(timeout, clientOptions...), cfg.SigningSecret))),
 			webhook.WithLogger(logger),
 		)
 
@@ -220,20 +265,17 @@ func emailTargetsFromConfig(
 
 	out := make([]kit.Target, 0, len(receiverCfg.Emails))
 	for idx, cfg := range receiverCfg.Emails {
-		tmpl, err := templates.LoadSource(
-			templateFS,
-			emailTemplateSource(firstNonEmpty(cfg.Template, hb.EmailTemplate)),
-			templateFuncs...,
-		)
+		htmlTmpl, err := loadTemplate(templateFS, emailTemplateSource(firstNonEmpty(cfg.Template, hb.EmailTemplate)))
 		if err != nil {
 			return nil, fmt.Errorf("load email[%d] template: %w", idx, err)
 		}
 
-		subjectTmpl, err := templates.ParseStringTemplate(
-			"email-subject",
-			subjectTemplate(hb.SubjectTmpl, cfg.SubjectTmpl),
-			templateFuncs...,
-		)
+		textTmpl, err := loadTemplate(templateFS, emailTextTemplateSource(firstNonEmpty(cfg.TextTemplate, hb.EmailTextTemplate)))
+		if err != nil {
+			return nil, fmt.Errorf("load email[%d] text template: %w", idx, err)
+		}
+
+		subjectTmpl, err := parseTemplate(templateFS, "email-subject", subjectTemplate(hb.SubjectTmpl, cfg.SubjectTmpl))
 		if err != nil {
 			return nil, fmt.Errorf("parse email[%d] subject template: %w", idx, err)
 		}
@@ -243,7 +285,7 @@ func emailTargetsFromConfig(
 			port = defaultEmailPort
 		}
 
-		target := email.NewFromTarget(email.Target{
+		target := &emailTarget{
 			Name:          indexedTargetName(receiverName, idx, len(receiverCfg.Emails)),
 			Host:          cfg.Host,
 			Port:          port,
@@ -252,10 +294,14 @@ func emailTargetsFromConfig(
 			From:          cfg.From,
 			To:            append([]string(nil), cfg.To...),
 			Headers:       maps.Clone(cfg.Headers),
+			StartTLS:      cfg.StartTLS,
+			SSL:           cfg.SSL,
 			SkipTLSVerify: cfg.InsecureSkipVerify,
-			Template:      tmpl,
+			StatusImages:  cfg.StatusImages,
+			HTMLTmpl:      htmlTmpl,
+			TextTmpl:      textTmpl,
 			SubjectTmpl:   subjectTmpl,
-		})
+		}
 
 		if err := validateEmailTarget(target, receiverName, receiverCfg.Vars); err != nil {
 			return nil, fmt.Errorf("validate email[%d] template: %w", idx, err)
@@ -308,6 +354,15 @@ func emailTemplateSource(value string) string {
 	}
 }
 
+func emailTextTemplateSource(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "default", "text":
+		return "builtin:email-text"
+	default:
+		return value
+	}
+}
+
 func firstNonEmpty(values ...string) string {
 	for _, value := range values {
 		if strings.TrimSpace(value) != "" {
@@ -330,7 +385,7 @@ func validateWebhookTarget(target *webhook.Target, receiverName string, vars map
 	return nil
 }
 
-func validateEmailTarget(target *email.Target, receiverName string, vars map[string]any) error {
+func validateEmailTarget(target *emailTarget, receiverName string, vars map[string]any) error {
 	for _, event := range validationEvents() {
 		if err := target.Validate(kit.Payload{
 			Notification: event,
@@ -501,6 +556,10 @@ func durationValue(value any) (time.Duration, bool) {
 }
 
 func sortedHeartbeatIDs(values map[string]config.HeartbeatConfig) []string {
+	return sortedKeys(values)
+}
+
+func sortedKeys[V any](values map[string]V) []string {
 	ids := make([]string, 0, len(values))
 	for id := range values {
 		ids = append(ids, id)
@@ -11,6 +11,7 @@ type Data struct {
 	Status      string
 	Subject     string
 	Payload     string
+	Reason      string
 	Timestamp   time.Time
 	Interval    time.Duration
 	LateAfter   time.Duration
@@ -18,6 +19,7 @@ type Data struct {
 	Vars        map[string]any
 	CustomData  map[string]string
 	Since       time.Duration
+	StatusImage string // cid: URL of the embedded status image in email bodies; empty when disabled.
 }
 
 // NewData builds template data from a heartbeat event and notifykit receiver vars.
@@ -28,6 +30,7 @@ func NewData(event Event, receiver string, vars map[string]any, subject string)
 		Status:      event.StatusValue,
 		Subject:     subject,
 		Payload:     event.Body,
+		Reason:      event.Reason,
 		Timestamp:   event.Time,
 		Interval:    event.Interval,
 		LateAfter:   event.LateAfter,
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Response is the HTTP outcome of a webhook delivery.
+type Response struct {
+	mu         sync.Mutex
+	statusCode int
+	retryAfter time.Duration
+}
+
+// StatusCode returns the status of the last response, or 0 without one.
+func (r *Response) StatusCode() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.statusCode
+}
+
+// RetryAfter returns the delay requested by the Retry-After header, or 0.
+func (r *Response) RetryAfter() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.retryAfter
+}
+
+type responseKey struct{}
+
+// WithResponse returns a context that captures the webhook response of a
+// delivery sent with it.
+func WithResponse(ctx context.Context) (context.Context, *Response) {
+	resp := &Response{}
+	return context.WithValue(ctx, responseKey{}, resp), resp
+}
+
+// recordingTransport stores response status and Retry-After in the
+// Response carried by the request context.
+type recordingTransport struct {
+	next http.RoundTripper
+}
+
+// RecordResponses wraps the transport of a webhook client so deliveries sent
+// with a WithResponse context capture the response.
+func RecordResponses(client *http.Client) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = recordingTransport{next: next}
+	return client
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	rec, ok := req.Context().Value(responseKey{}).(*Response)
+	if !ok || resp == nil {
+		return resp, err
+	}
+	rec.mu.Lock()
+	rec.statusCode = resp.StatusCode
+	rec.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	rec.mu.Unlock()
+	return resp, err
+}
+
+// parseRetryAfter parses delay-seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
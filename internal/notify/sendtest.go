@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"time"
+
+	"github.com/containeroo/heartbeats/internal/config"
+
+	kit "github.com/containeroo/notifykit/notify"
+)
+
+// ErrUnknownReceiver marks a test of a receiver missing from the config.
+var ErrUnknownReceiver = errors.New("unknown receiver")
+
+// TargetResult is the outcome of a test delivery to one receiver target.
+type TargetResult struct {
+	Type        string `json:"type"`                 // Delivery target type.
+	Destination string `json:"destination"`          // Redacted webhook URL or email recipients.
+	OK          bool   `json:"ok"`                   // Whether the target accepted the notification.
+	StatusCode  int    `json:"statusCode,omitempty"` // Webhook response status.
+	Error       string `json:"error,omitempty"`      // Delivery error.
+	DurationMS  int64  `json:"durationMs"`           // Time taken by the delivery.
+}
+
+// SendTest builds the receiver name from cfg and sends a synthetic event to
+// each of its targets, bypassing the notification queue and its retries.
+func SendTest(ctx context.Context, templateFS fs.FS, cfg *config.Config, name string, logger *slog.Logger) ([]TargetResult, error) {
+	receiverCfg, ok := cfg.Receivers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownReceiver, name)
+	}
+	id := kit.ReceiverID("test.receiver." + name)
+	receiver, err := receiverFromConfig(templateFS, id, config.HeartbeatConfig{}, name, receiverCfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("receiver %q: %w", name, err)
+	}
+
+	event := NewEvent(
+		"test",
+		"Test notification",
+		"test",
+		"This is a test notification sent from the heartbeats dashboard or API.",
+		0,
+		time.Now(),
+		0,
+		0,
+		[]kit.ReceiverID{id},
+	)
+	results := make([]TargetResult, 0, len(receiver.Targets))
+	for _, target := range receiver.Targets {
+		sendCtx, resp := WithResponse(ctx)
+		start := time.Now()
+		err := target.Send(sendCtx, kit.Payload{
+			Notification: event,
+			Receiver:     receiver.Name,
+			CustomData:   receiver.CustomData,
+		})
+		result := TargetResult{
+			Type:        TargetType(target),
+			Destination: RedactDestination(TargetType(target), TargetDestination(target)),
+			OK:          err == nil,
+			StatusCode:  resp.StatusCode(),
+			DurationMS:  time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
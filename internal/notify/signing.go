@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers of signed webhook requests.
+const (
+	SignatureHeader = "X-Heartbeat-Signature" // sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+	TimestampHeader = "X-Heartbeat-Timestamp" // Unix seconds the request was signed at.
+)
+
+// signingTransport adds a timestamp and an HMAC-SHA256 signature over the
+// timestamp and body to every request. Each attempt is signed anew, so
+// retries carry a fresh timestamp.
+type signingTransport struct {
+	next   http.RoundTripper
+	secret []byte
+	now    func() time.Time
+}
+
+// SignRequests wraps the transport of a webhook client so every request is
+// signed with secret. An empty secret returns the client unchanged.
+func SignRequests(client *http.Client, secret string) *http.Client {
+	if secret == "" {
+		return client
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = signingTransport{next: next, secret: []byte(secret), now: time.Now}
+	return client
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close() // nolint:errcheck
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	signed := req.Clone(req.Context())
+	signed.Body = io.NopCloser(bytes.NewReader(body))
+	signed.ContentLength = int64(len(body))
+	timestamp := t.now().Unix()
+	signed.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	signed.Header.Set(SignatureHeader, Signature(t.secret, timestamp, body))
+	return t.next.RoundTrip(signed)
+}
+
+// Signature returns the signature header value of body sent at timestamp:
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>".
+func Signature(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignRequests(t *testing.T) {
+	t.Parallel()
+
+	t.Run("signs timestamp and body", func(t *testing.T) {
+		t.Parallel()
+		var header http.Header
+		var body []byte
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header.Clone()
+			body, _ = io.ReadAll(r.Body)
+		}))
+		defer srv.Close()
+
+		client := SignRequests(&http.Client{}, "s3cret")
+		resp, err := client.Post(srv.URL, "application/json", strings.NewReader(`{"status":"late"}`))
+		require.NoError(t, err)
+		resp.Body.Close() // nolint:errcheck
+
+		assert.Equal(t, `{"status":"late"}`, string(body))
+		timestamp, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+		require.NoError(t, err)
+		assert.InDelta(t, time.Now().Unix(), timestamp, 5)
+		assert.Equal(t, Signature([]byte("s3cret"), timestamp, body), header.Get(SignatureHeader))
+	})
+
+	t.Run("matches the documented format", func(t *testing.T) {
+		t.Parallel()
+		// printf '%s' '1700000000.{}' | openssl dgst -sha256 -hmac s3cret
+		assert.Equal(t,
+			"sha256=97926816e98fbb41ccb1673225ff29a2f35369099990e1b1561651e7bd097ebf",
+			Signature([]byte("s3cret"), 1700000000, []byte("{}")),
+		)
+	})
+
+	t.Run("leaves unsigned clients unchanged", func(t *testing.T) {
+		t.Parallel()
+		client := &http.Client{}
+		assert.Nil(t, SignRequests(client, "").Transport)
+	})
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"strings"
+
+	kit "github.com/containeroo/notifykit/notify"
+	"github.com/containeroo/notifykit/targets/webhook"
+
+	"github.com/containeroo/heartbeats/internal/config"
+)
+
+// TargetType returns the delivery type of a target, e.g. webhook or email.
+func TargetType(target kit.Target) string {
+	switch target.(type) {
+	case *webhook.Target:
+		return "webhook"
+	default:
+		typed, ok := target.(interface{ Type() string })
+		if ok {
+			return typed.Type()
+		}
+		return "unknown"
+	}
+}
+
+// TargetDestination returns where a target delivers to: the webhook URL or
+// the email recipients.
+func TargetDestination(target kit.Target) string {
+	switch t := target.(type) {
+	case *webhook.Target:
+		return t.URL
+	case *emailTarget:
+		return strings.Join(t.To, ", ")
+	default:
+		typed, ok := target.(interface{ Destination() string })
+		if ok {
+			return typed.Destination()
+		}
+		return ""
+	}
+}
+
+// RedactDestination returns a destination that is safe to show: webhook URLs
+// carry tokens in their path, query, or userinfo, so only their scheme and
+// host are kept (see config.RedactURL). Email recipients are returned as is.
+func RedactDestination(targetType, destination string) string {
+	if targetType == "webhook" {
+		return config.RedactURL(destination)
+	}
+	return destination
+}
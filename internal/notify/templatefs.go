@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/containeroo/notifykit/templates"
+)
+
+// TemplateFS returns the built-in templates overlaid by the files in dir: a
+// file in dir replaces the built-in template of the same name, and every
+// *.tmpl file in dir can define blocks used by other templates. Files are
+// read on every load, so a reload picks up changes. An empty dir returns base.
+func TemplateFS(base fs.FS, dir string) fs.FS {
+	if dir == "" {
+		return base
+	}
+	return overlayFS{dir: os.DirFS(dir), base: base}
+}
+
+// overlayFS serves files from dir and falls back to base.
+type overlayFS struct {
+	dir  fs.FS
+	base fs.FS
+}
+
+// Open implements fs.FS.
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.dir.Open(name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return f, err
+	}
+	return o.base.Open(name)
+}
+
+// ReadDir implements fs.ReadDirFS by merging both directories.
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(o.dir, name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	baseEntries, baseErr := fs.ReadDir(o.base, name)
+	if baseErr != nil && !errors.Is(baseErr, fs.ErrNotExist) {
+		return nil, baseErr
+	}
+	if err != nil && baseErr != nil {
+		return nil, err
+	}
+	for _, entry := range baseEntries {
+		if !slices.ContainsFunc(entries, func(e fs.DirEntry) bool { return e.Name() == entry.Name() }) {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+// ReadTemplate reads a template source: builtin:<name> from templateFS,
+// a relative path from the template directory if it has the file, and
+// anything else from disk.
+func ReadTemplate(templateFS fs.FS, source string) (string, error) {
+	var (
+		data []byte
+		err  error
+	)
+	overlay, hasDir := templateFS.(overlayFS)
+	if name, ok := strings.CutPrefix(source, "builtin:"); ok {
+		data, err = fs.ReadFile(templateFS, name+".tmpl")
+	} else if hasDir && fs.ValidPath(source) && fileExists(overlay.dir, source) {
+		data, err = fs.ReadFile(overlay.dir, source)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read template %q: %w", source, err)
+	}
+	return string(data), nil
+}
+
+// AddPartials parses every *.tmpl file of the template directory into tmpl,
+// making their define blocks available to it. Built-in templates are not
+// added.
+func AddPartials(tmpl *template.Template, templateFS fs.FS) error {
+	overlay, ok := templateFS.(overlayFS)
+	if !ok {
+		return nil
+	}
+	names, err := fs.Glob(overlay.dir, "*.tmpl")
+	if err != nil {
+		return fmt.Errorf("list partials: %w", err)
+	}
+	for _, name := range names {
+		if name == tmpl.Name() {
+			continue
+		}
+		data, err := fs.ReadFile(overlay.dir, name)
+		if err != nil {
+			return fmt.Errorf("read partial %q: %w", name, err)
+		}
+		if _, err := tmpl.New(name).Parse(string(data)); err != nil {
+			return fmt.Errorf("parse partial %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// loadTemplate parses a template source with the heartbeat helpers and the
+// partials of the template directory.
+func loadTemplate(templateFS fs.FS, source string) (*template.Template, error) {
+	text, err := ReadTemplate(templateFS, source)
+	if err != nil {
+		return nil, err
+	}
+	return parseTemplate(templateFS, templateName(source), text)
+}
+
+// parseTemplate parses text with the heartbeat helpers and the partials of
+// the template directory.
+func parseTemplate(templateFS fs.FS, name, text string) (*template.Template, error) {
+	tmpl, err := templates.ParseStringTemplate(name, text, templateFuncs...)
+	if err != nil {
+		return nil, err
+	}
+	if err := AddPartials(tmpl, templateFS); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// templateName names a template after its file, so a template directory
+// file is not parsed twice as its own partial.
+func templateName(source string) string {
+	if name, ok := strings.CutPrefix(source, "builtin:"); ok {
+		return name + ".tmpl"
+	}
+	return source
+}
+
+// fileExists reports whether name is a regular file in fsys.
+func fileExists(fsys fs.FS, name string) bool {
+	info, err := fs.Stat(fsys, name)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,531 @@
+// Package outbox persists outgoing notifications until every target accepted
+// them. Failed deliveries are retried with exponential backoff; entries that
+// exhaust their retries are kept as dead letters that can be replayed.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	kit "github.com/containeroo/notifykit/notify"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/history"
+	"github.com/containeroo/heartbeats/internal/logging"
+	"github.com/containeroo/heartbeats/internal/notify"
+	"github.com/containeroo/heartbeats/internal/utils"
+)
+
+const (
+	// DefaultRetryCount is the number of retries when a receiver sets none.
+	DefaultRetryCount = 5
+	// DefaultRetryDelay is the first retry delay when a receiver sets none.
+	DefaultRetryDelay = 10 * time.Second
+	// DefaultMaxRetryDelay caps a single delay when a receiver sets no max_delay.
+	DefaultMaxRetryDelay = 10 * time.Minute
+	// DefaultFailedLimit is the number of dead letters kept; older ones are dropped.
+	DefaultFailedLimit = 500
+	// DefaultWorkers is the number of concurrent deliveries.
+	DefaultWorkers = 4
+
+	idleWait = time.Minute // Upper bound for a worker to sleep without a signal.
+)
+
+var (
+	// ErrNotFound marks an unknown dead letter.
+	ErrNotFound = errors.New("not found")
+
+	errReceiverGone = errors.New("receiver target no longer configured")
+)
+
+// Entry is one notification queued for one receiver target.
+type Entry struct {
+	ID          string         `json:"id"`                    // Queue entry id.
+	ReceiverID  kit.ReceiverID `json:"receiverId"`            // notifykit receiver id.
+	Receiver    string         `json:"receiver"`              // Receiver name.
+	Target      int            `json:"target"`                // Index of the target when queued; breaks ties between identical targets.
+	TargetType  string         `json:"targetType"`            // Delivery target type; identifies the target with Destination.
+	Destination string         `json:"destination,omitempty"` // Webhook URL or email recipients; redacted outside the queue.
+	Event       *notify.Event  `json:"event"`                 // Notification to deliver.
+	Attempts    int            `json:"attempts"`              // Failed delivery attempts so far.
+	NextAttempt time.Time      `json:"nextAttempt,omitzero"`  // Earliest time of the next attempt.
+	LastError   string         `json:"lastError,omitempty"`   // Error of the last attempt.
+	LastStatus  int            `json:"lastStatus,omitempty"`  // Webhook response status of the last attempt.
+	FailedAt    time.Time      `json:"failedAt,omitzero"`     // When the entry was dead-lettered.
+}
+
+// state is the persisted queue.
+type state struct {
+	Pending []Entry `json:"pending"` // Entries waiting for delivery.
+	Failed  []Entry `json:"failed"`  // Dead letters, oldest first.
+}
+
+// Queue delivers notifications to receiver targets and persists them to an
+// optional file until they are delivered or dead-lettered.
+type Queue struct {
+	path    string
+	history history.Recorder
+	logger  *slog.Logger
+	now     func() time.Time
+
+	mu        sync.Mutex
+	receivers func() kit.Receivers          // Current receivers; the map is not modified.
+	retry     map[string]config.RetryConfig // Retry policy by receiver name.
+	pending   []Entry
+	failed    []Entry
+	inflight  map[string]bool
+	wake      chan struct{} // Closed to wake idle workers.
+}
+
+// New loads the queue from path. An empty path keeps entries in memory; a
+// missing file starts an empty queue. Entries left pending by a previous run
+// are delivered once the queue is started.
+func New(path string, receivers kit.Receivers, recorder history.Recorder, logger *slog.Logger) (*Queue, error) {
+	q := &Queue{
+		path:      path,
+		history:   recorder,
+		logger:    logger,
+		now:       time.Now,
+		receivers: staticReceivers(receivers),
+		inflight:  make(map[string]bool),
+		wake:      make(chan struct{}),
+	}
+	if path == "" {
+		return q, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read notification queue: %w", err)
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("parse notification queue %q: %w", path, err)
+	}
+	noEvent := func(e Entry) bool { return e.Event == nil }
+	q.pending = slices.DeleteFunc(st.Pending, noEvent)
+	q.failed = slices.DeleteFunc(st.Failed, noEvent)
+	return q, nil
+}
+
+// SetReceivers replaces the receivers notifications are delivered to.
+func (q *Queue) SetReceivers(receivers kit.Receivers) {
+	q.SetReceiverSource(staticReceivers(receivers))
+}
+
+// SetReceiverSource makes the queue look up receivers through fn whenever it
+// queues or delivers a notification, e.g. from the active config generation,
+// so it never lags behind a reload. The returned map must not be modified.
+func (q *Queue) SetReceiverSource(fn func() kit.Receivers) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.receivers = fn
+}
+
+// staticReceivers returns a receiver source for a copy of receivers.
+func staticReceivers(receivers kit.Receivers) func() kit.Receivers {
+	receivers = maps.Clone(receivers)
+	return func() kit.Receivers { return receivers }
+}
+
+// SetConfig records the retry policy of every receiver.
+func (q *Queue) SetConfig(cfg *config.Config) {
+	retry := make(map[string]config.RetryConfig, len(cfg.Receivers))
+	for name, rcv := range cfg.Receivers {
+		retry[name] = rcv.Retry
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.retry = retry
+}
+
+// Receivers returns the configured receivers ordered by id.
+func (q *Queue) Receivers() []*kit.Receiver {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := slices.Collect(maps.Values(q.receivers()))
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Enqueue queues a notification for every target of its receivers and
+// returns the notification id, or an empty id when no target matched.
+func (q *Queue) Enqueue(_ context.Context, n kit.Notification) (string, error) {
+	event, ok := n.(*notify.Event)
+	if !ok || event == nil {
+		return "", fmt.Errorf("unsupported notification %T", n)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	added := 0
+	receivers := q.receivers()
+	for _, receiverID := range event.ReceiverIDs() {
+		rcv, ok := receivers[receiverID]
+		if !ok || rcv == nil {
+			q.logger.Warn("Notification receiver missing",
+				"event", logging.EventReceiverMissing.String(),
+				"notification", event.ID(),
+				"receiver", string(receiverID),
+			)
+			continue
+		}
+		for idx, target := range rcv.Targets {
+			if target == nil {
+				continue
+			}
+			q.pending = append(q.pending, Entry{
+				ID:          fmt.Sprintf("%s.%d", event.ID(), added),
+				ReceiverID:  receiverID,
+				Receiver:    rcv.Name,
+				Target:      idx,
+				TargetType:  notify.TargetType(target),
+				Destination: notify.TargetDestination(target),
+				Event:       event,
+				NextAttempt: now,
+			})
+			added++
+		}
+	}
+	if added == 0 {
+		return "", nil
+	}
+	q.saveLocked()
+	q.signalLocked()
+	return event.ID(), nil
+}
+
+// Failed returns the dead letters, most recent first. Destinations are
+// redacted, since webhook URLs may carry tokens.
+func (q *Queue) Failed() []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := slices.Clone(q.failed)
+	slices.Reverse(out)
+	for idx := range out {
+		out[idx].Destination = notify.RedactDestination(out[idx].TargetType, out[idx].Destination)
+	}
+	return out
+}
+
+// Replay moves a dead letter back into the queue with a fresh retry budget.
+func (q *Queue) Replay(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	idx := slices.IndexFunc(q.failed, func(e Entry) bool { return e.ID == id })
+	if idx < 0 {
+		return fmt.Errorf("%w: notification %q", ErrNotFound, id)
+	}
+	entry := q.failed[idx]
+	q.failed = slices.Delete(q.failed, idx, idx+1)
+	entry.Attempts = 0
+	entry.LastError = ""
+	entry.LastStatus = 0
+	entry.FailedAt = time.Time{}
+	entry.NextAttempt = q.now()
+	q.pending = append(q.pending, entry)
+	q.saveLocked()
+	q.signalLocked()
+
+	q.logger.Info("Notification replayed",
+		"event", logging.EventNotificationReplayed.String(),
+		"queue_id", entry.ID,
+		"heartbeat", entry.Event.Heartbeat,
+		"receiver", entry.Receiver,
+		"target", entry.TargetType,
+	)
+	return nil
+}
+
+// Start launches the delivery workers. They stop when ctx is done; entries
+// still pending stay queued for the next start.
+func (q *Queue) Start(ctx context.Context) {
+	for range DefaultWorkers {
+		go q.work(ctx)
+	}
+}
+
+// work delivers due entries and sleeps until the next one is due.
+func (q *Queue) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		entry, wake, wait, ok := q.next()
+		if ok {
+			q.deliver(ctx, entry)
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+		case <-wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// next claims the oldest due entry. Without one, it returns the channel that
+// signals new work and how long to wait for the next retry.
+func (q *Queue) next() (Entry, <-chan struct{}, time.Duration, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	wait := idleWait
+	for _, entry := range q.pending {
+		if q.inflight[entry.ID] {
+			continue
+		}
+		if !entry.NextAttempt.After(now) {
+			q.inflight[entry.ID] = true
+			return entry, nil, 0, true
+		}
+		wait = min(wait, entry.NextAttempt.Sub(now))
+	}
+	return Entry{}, q.wake, wait, false
+}
+
+// entryTarget finds the target of entry in rcv by type and destination, so
+// reloads and edits that reorder targets cannot redirect a queued entry.
+// It returns nil when the target is no longer configured.
+func entryTarget(rcv *kit.Receiver, entry Entry) kit.Target {
+	if rcv == nil {
+		return nil
+	}
+	var match kit.Target
+	for idx, target := range rcv.Targets {
+		if target == nil || notify.TargetType(target) != entry.TargetType || notify.TargetDestination(target) != entry.Destination {
+			continue
+		}
+		if idx == entry.Target {
+			return target
+		}
+		if match == nil {
+			match = target
+		}
+	}
+	return match
+}
+
+// deliver sends an entry to its target and records the outcome.
+func (q *Queue) deliver(ctx context.Context, entry Entry) {
+	q.mu.Lock()
+	rcv := q.receivers()[entry.ReceiverID]
+	target := entryTarget(rcv, entry)
+	q.mu.Unlock()
+
+	sendCtx, resp := notify.WithResponse(ctx)
+	err := errReceiverGone
+	if target != nil {
+		err = target.Send(sendCtx, kit.Payload{
+			Notification: entry.Event,
+			Receiver:     rcv.Name,
+			CustomData:   rcv.CustomData,
+		})
+	}
+	if err != nil && ctx.Err() != nil {
+		// Interrupted by shutdown; the attempt does not count.
+		q.mu.Lock()
+		delete(q.inflight, entry.ID)
+		q.mu.Unlock()
+		return
+	}
+	q.finish(entry, err, resp)
+}
+
+// finish removes a delivered entry, or schedules a retry or dead-letters a
+// failed one. Webhook responses with a status the policy does not retry,
+// such as most 4xx, are dead-lettered right away.
+func (q *Queue) finish(entry Entry, err error, resp *notify.Response) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.inflight, entry.ID)
+	idx := slices.IndexFunc(q.pending, func(e Entry) bool { return e.ID == entry.ID })
+	if idx < 0 {
+		return
+	}
+	now := q.now()
+
+	if err == nil {
+		q.pending = slices.Delete(q.pending, idx, idx+1)
+		q.saveLocked()
+		q.logger.Info("Notification delivered",
+			"event", logging.EventNotificationTargetDelivered.String(),
+			"queue_id", entry.ID,
+			"heartbeat", entry.Event.Heartbeat,
+			"receiver", entry.Receiver,
+			"target", entry.TargetType,
+			"attempts", entry.Attempts+1,
+		)
+		q.record(history.EventNotificationDelivered, entry, now, "", nil)
+		return
+	}
+
+	entry.Attempts++
+	entry.LastError = err.Error()
+	entry.LastStatus = resp.StatusCode()
+	policy := q.policyLocked(entry.Receiver)
+	permanent := errors.Is(err, errReceiverGone) ||
+		(entry.LastStatus != 0 && !policy.RetriesStatus(entry.LastStatus))
+	if permanent || entry.Attempts > *policy.Count {
+		entry.NextAttempt = time.Time{}
+		entry.FailedAt = now
+		q.pending = slices.Delete(q.pending, idx, idx+1)
+		q.failed = append(q.failed, entry)
+		if over := len(q.failed) - DefaultFailedLimit; over > 0 {
+			q.failed = slices.Delete(q.failed, 0, over)
+		}
+		q.saveLocked()
+		q.logger.Error("Notification dead-lettered",
+			"event", logging.EventNotificationDeadLettered.String(),
+			"queue_id", entry.ID,
+			"heartbeat", entry.Event.Heartbeat,
+			"receiver", entry.Receiver,
+			"target", entry.TargetType,
+			"attempts", entry.Attempts,
+			"err", err,
+		)
+		q.record(history.EventNotificationFailed, entry, now, entry.LastError, nil)
+		return
+	}
+
+	delay := jitter(Backoff(policy, entry.Attempts), policy.Jitter)
+	if retryAfter := resp.RetryAfter(); retryAfter > delay {
+		delay = min(retryAfter, policy.MaxDelay)
+	}
+	entry.NextAttempt = now.Add(delay)
+	q.pending[idx] = entry
+	q.saveLocked()
+	q.logger.Warn("Notification delivery failed",
+		"event", logging.EventNotificationTargetFailed.String(),
+		"queue_id", entry.ID,
+		"heartbeat", entry.Event.Heartbeat,
+		"receiver", entry.Receiver,
+		"target", entry.TargetType,
+		"attempts", entry.Attempts,
+		"status_code", entry.LastStatus,
+		"retry_in", delay.String(),
+		"err", err,
+	)
+	q.record(history.EventNotificationRetried, entry, now, entry.LastError, map[string]any{
+		"retry_in":     delay.String(),
+		"next_attempt": entry.NextAttempt.UTC().Format(time.RFC3339),
+	})
+}
+
+// Backoff returns the delay before retry attempt n (starting at 1) of a
+// policy with defaults applied: always delay for fixed backoff, otherwise
+// delay doubled per attempt. Both are capped at max_delay.
+func Backoff(policy config.RetryConfig, n int) time.Duration {
+	delay := policy.Delay
+	if policy.Backoff != config.BackoffFixed {
+		for i := 1; i < n && delay < policy.MaxDelay; i++ {
+			delay *= 2
+		}
+	}
+	return min(delay, policy.MaxDelay)
+}
+
+// jitter spreads delay randomly by up to ±fraction of it.
+func jitter(delay time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return delay
+	}
+	spread := float64(delay) * fraction
+	return delay + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// policyLocked returns the retry policy of a receiver with defaults applied.
+func (q *Queue) policyLocked(receiver string) config.RetryConfig {
+	policy := q.retry[receiver]
+	if policy.Count == nil {
+		policy.Count = utils.ToPtr(DefaultRetryCount)
+	}
+	policy.Delay = utils.DefaultIfZero(policy.Delay, DefaultRetryDelay)
+	policy.MaxDelay = utils.DefaultIfZero(policy.MaxDelay, DefaultMaxRetryDelay)
+	policy.Backoff = utils.DefaultIfZero(policy.Backoff, config.BackoffExponential)
+	return policy
+}
+
+// record adds a delivery attempt or outcome to the history.
+func (q *Queue) record(typ history.EventType, entry Entry, now time.Time, message string, extra map[string]any) {
+	if q.history == nil {
+		return
+	}
+	fields := map[string]any{
+		"target":   notify.RedactDestination(entry.TargetType, entry.Destination),
+		"queue_id": entry.ID,
+		"attempts": entry.Attempts,
+	}
+	if entry.LastStatus != 0 {
+		fields["status_code"] = entry.LastStatus
+	}
+	maps.Copy(fields, extra)
+	q.history.Add(history.Event{
+		Time:        now.UTC(),
+		Type:        typ.String(),
+		HeartbeatID: entry.Event.Heartbeat,
+		Receiver:    entry.Receiver,
+		TargetType:  entry.TargetType,
+		Status:      entry.Event.StatusValue,
+		Message:     message,
+		Fields:      fields,
+	})
+}
+
+// signalLocked wakes every idle worker.
+func (q *Queue) signalLocked() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
+
+// saveLocked writes the queue to its file; failures are logged because the
+// entries are still delivered from memory.
+func (q *Queue) saveLocked() {
+	if q.path == "" {
+		return
+	}
+	if err := q.write(state{Pending: q.pending, Failed: q.failed}); err != nil {
+		q.logger.Error("Notification queue save failed",
+			"event", logging.EventNotificationQueueSaveFailed.String(),
+			"path", q.path,
+			"err", err,
+		)
+	}
+}
+
+// write replaces the queue file atomically.
+func (q *Queue) write(st state) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("encode notification queue: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), ".queue-*.json")
+	if err != nil {
+		return fmt.Errorf("write notification queue: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write notification queue: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write notification queue: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), q.path); err != nil {
+		return fmt.Errorf("write notification queue: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,406 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kit "github.com/containeroo/notifykit/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/history"
+	"github.com/containeroo/heartbeats/internal/notify"
+	"github.com/containeroo/heartbeats/internal/utils"
+)
+
+// flakyTarget fails its first sends, then delivers.
+type flakyTarget struct {
+	mu       sync.Mutex
+	failures int
+	sent     int
+}
+
+func (t *flakyTarget) Send(context.Context, kit.Payload) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.failures > 0 {
+		t.failures--
+		return errors.New("connection refused")
+	}
+	t.sent++
+	return nil
+}
+
+func (t *flakyTarget) Sent() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sent
+}
+
+// namedTarget is a flaky target with a destination.
+type namedTarget struct {
+	flakyTarget
+	url string
+}
+
+func (t *namedTarget) Type() string        { return "webhook" }
+func (t *namedTarget) Destination() string { return t.url }
+
+func newQueue(t *testing.T, path string, target kit.Target, retry config.RetryConfig) (*Queue, *history.Store) {
+	t.Helper()
+	receivers := kit.Receivers{
+		"heartbeat.api.receiver.ops": {ID: "heartbeat.api.receiver.ops", Name: "ops", Targets: []kit.Target{target}},
+	}
+	store := history.NewStore(50)
+	q, err := New(path, receivers, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	q.SetConfig(&config.Config{Receivers: map[string]config.ReceiverConfig{"ops": {Retry: retry}}})
+	return q, store
+}
+
+func event() *notify.Event {
+	return notify.NewEvent("api", "api", "late", "", time.Minute, time.Now(), time.Minute, time.Minute,
+		[]kit.ReceiverID{"heartbeat.api.receiver.ops"})
+}
+
+func eventTypes(events []history.Event) []string {
+	out := make([]string, 0, len(events))
+	for _, ev := range events {
+		out = append(out, ev.Type)
+	}
+	return out
+}
+
+func TestQueue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("delivers queued notifications", func(t *testing.T) {
+		t.Parallel()
+		target := &flakyTarget{failures: 1}
+		q, store := newQueue(t, "", target, config.RetryConfig{Count: utils.ToPtr(2), Delay: time.Millisecond})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		q.Start(ctx)
+
+		id, err := q.Enqueue(ctx, event())
+		require.NoError(t, err)
+		assert.NotEmpty(t, id)
+
+		assert.Eventually(t, func() bool { return target.Sent() == 1 }, time.Second, 5*time.Millisecond)
+		assert.Eventually(t, func() bool { return len(store.List()) == 2 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, []string{
+			history.EventNotificationRetried.String(),
+			history.EventNotificationDelivered.String(),
+		}, eventTypes(store.List()))
+		assert.Equal(t, "ops", store.List()[1].Receiver)
+		assert.Empty(t, q.Failed())
+	})
+
+	t.Run("dead-letters exhausted notifications and replays them", func(t *testing.T) {
+		t.Parallel()
+		target := &flakyTarget{failures: 3}
+		q, store := newQueue(t, "", target, config.RetryConfig{Count: utils.ToPtr(2), Delay: time.Millisecond})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		q.Start(ctx)
+
+		_, err := q.Enqueue(ctx, event())
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool { return len(q.Failed()) == 1 }, time.Second, 5*time.Millisecond)
+		failed := q.Failed()[0]
+		assert.Equal(t, 3, failed.Attempts)
+		assert.Equal(t, "connection refused", failed.LastError)
+		assert.False(t, failed.FailedAt.IsZero())
+		assert.Equal(t, []string{
+			history.EventNotificationRetried.String(),
+			history.EventNotificationRetried.String(),
+			history.EventNotificationFailed.String(),
+		}, eventTypes(store.List()))
+
+		require.NoError(t, q.Replay(failed.ID))
+		assert.Eventually(t, func() bool { return target.Sent() == 1 }, time.Second, 5*time.Millisecond)
+		assert.Empty(t, q.Failed())
+	})
+
+	t.Run("count 0 disables retries", func(t *testing.T) {
+		t.Parallel()
+		target := &flakyTarget{failures: 1}
+		q, store := newQueue(t, "", target, config.RetryConfig{Count: utils.ToPtr(0), Delay: time.Millisecond})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		q.Start(ctx)
+
+		_, err := q.Enqueue(ctx, event())
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool { return len(q.Failed()) == 1 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 1, q.Failed()[0].Attempts)
+		assert.Equal(t, []string{history.EventNotificationFailed.String()}, eventTypes(store.List()))
+	})
+
+	t.Run("defaults to 5 retries without count", func(t *testing.T) {
+		t.Parallel()
+		q, _ := newQueue(t, "", &flakyTarget{}, config.RetryConfig{})
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		assert.Equal(t, DefaultRetryCount, *q.policyLocked("ops").Count)
+	})
+
+	t.Run("replay of unknown entry", func(t *testing.T) {
+		t.Parallel()
+		q, _ := newQueue(t, "", &flakyTarget{}, config.RetryConfig{})
+		assert.ErrorIs(t, q.Replay("missing"), ErrNotFound)
+	})
+
+	t.Run("skips notifications without targets", func(t *testing.T) {
+		t.Parallel()
+		q, _ := newQueue(t, "", &flakyTarget{}, config.RetryConfig{})
+		ev := notify.NewEvent("api", "api", "late", "", 0, time.Now(), time.Minute, time.Minute,
+			[]kit.ReceiverID{"heartbeat.api.receiver.unknown"})
+		id, err := q.Enqueue(context.Background(), ev)
+		require.NoError(t, err)
+		assert.Empty(t, id)
+	})
+
+	t.Run("persists pending entries across restarts", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "queue.json")
+		target := &flakyTarget{failures: 1}
+		q, _ := newQueue(t, path, target, config.RetryConfig{Count: utils.ToPtr(1), Delay: time.Hour})
+		ctx, cancel := context.WithCancel(context.Background())
+		q.Start(ctx)
+		_, err := q.Enqueue(ctx, event())
+		require.NoError(t, err)
+		_, err = q.Enqueue(ctx, event())
+		require.NoError(t, err)
+		// One notification fails once and waits an hour for its retry.
+		assert.Eventually(t, func() bool {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			return len(q.pending) == 1 && q.pending[0].Attempts == 1
+		}, time.Second, 5*time.Millisecond)
+		cancel()
+
+		restarted, _ := newQueue(t, path, target, config.RetryConfig{Count: utils.ToPtr(1), Delay: time.Millisecond})
+		require.Len(t, restarted.pending, 1)
+		assert.Equal(t, 1, restarted.pending[0].Attempts)
+		assert.Equal(t, "api", restarted.pending[0].Event.Heartbeat)
+
+		restarted.pending[0].NextAttempt = time.Time{}
+		ctx, cancel = context.WithCancel(context.Background())
+		defer cancel()
+		restarted.Start(ctx)
+		assert.Eventually(t, func() bool { return target.Sent() == 2 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("follows its target when targets are reordered", func(t *testing.T) {
+		t.Parallel()
+		first, second := &namedTarget{url: "https://a.example.com"}, &namedTarget{url: "https://b.example.com"}
+		q, _ := newQueue(t, "", first, config.RetryConfig{})
+		_, err := q.Enqueue(context.Background(), event())
+		require.NoError(t, err)
+		q.SetReceivers(kit.Receivers{
+			"heartbeat.api.receiver.ops": {ID: "heartbeat.api.receiver.ops", Name: "ops", Targets: []kit.Target{second, first}},
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		q.Start(ctx)
+		assert.Eventually(t, func() bool { return first.Sent() == 1 }, time.Second, 5*time.Millisecond)
+		assert.Zero(t, second.Sent())
+	})
+
+	t.Run("dead-letters entries whose target changed", func(t *testing.T) {
+		t.Parallel()
+		q, _ := newQueue(t, "", &namedTarget{url: "https://a.example.com"}, config.RetryConfig{})
+		_, err := q.Enqueue(context.Background(), event())
+		require.NoError(t, err)
+		moved := &namedTarget{url: "https://c.example.com"}
+		q.SetReceivers(kit.Receivers{
+			"heartbeat.api.receiver.ops": {ID: "heartbeat.api.receiver.ops", Name: "ops", Targets: []kit.Target{moved}},
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		q.Start(ctx)
+		assert.Eventually(t, func() bool { return len(q.Failed()) == 1 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, errReceiverGone.Error(), q.Failed()[0].LastError)
+		assert.Zero(t, moved.Sent())
+	})
+
+	t.Run("redacts webhook destinations outside the queue", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "queue.json")
+		target := &namedTarget{flakyTarget: flakyTarget{failures: 1}, url: "https://hooks.example.com/services/T0K3N"}
+		q, store := newQueue(t, path, target, config.RetryConfig{Count: utils.ToPtr(0)})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		q.Start(ctx)
+		_, err := q.Enqueue(ctx, event())
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool { return len(q.Failed()) == 1 }, time.Second, 5*time.Millisecond)
+		redacted := "https://hooks.example.com/" + config.RedactedSecret
+		assert.Equal(t, redacted, q.Failed()[0].Destination)
+		events := store.List()
+		require.NotEmpty(t, events)
+		assert.Equal(t, redacted, events[len(events)-1].Fields["target"])
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "T0K3N", "the queue keeps the URL to deliver replays")
+	})
+
+	t.Run("reads receivers from its source", func(t *testing.T) {
+		t.Parallel()
+		q, _ := newQueue(t, "", &flakyTarget{}, config.RetryConfig{})
+		var active atomic.Pointer[kit.Receivers]
+		active.Store(&kit.Receivers{})
+		q.SetReceiverSource(func() kit.Receivers { return *active.Load() })
+
+		id, err := q.Enqueue(context.Background(), event())
+		require.NoError(t, err)
+		assert.Empty(t, id, "unknown receivers are skipped")
+
+		active.Store(&kit.Receivers{
+			"heartbeat.api.receiver.ops": {ID: "heartbeat.api.receiver.ops", Name: "ops", Targets: []kit.Target{&flakyTarget{}}},
+		})
+		id, err = q.Enqueue(context.Background(), event())
+		require.NoError(t, err)
+		assert.NotEmpty(t, id, "swapped receivers are used without another call")
+	})
+
+	t.Run("dead-letters entries of removed receivers", func(t *testing.T) {
+		t.Parallel()
+		q, _ := newQueue(t, "", &flakyTarget{}, config.RetryConfig{})
+		_, err := q.Enqueue(context.Background(), event())
+		require.NoError(t, err)
+		q.SetReceivers(kit.Receivers{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		q.Start(ctx)
+		assert.Eventually(t, func() bool { return len(q.Failed()) == 1 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, errReceiverGone.Error(), q.Failed()[0].LastError)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("exponential", func(t *testing.T) {
+		t.Parallel()
+		policy := config.RetryConfig{Delay: time.Second, MaxDelay: time.Minute, Backoff: config.BackoffExponential}
+		assert.Equal(t, time.Second, Backoff(policy, 1))
+		assert.Equal(t, 2*time.Second, Backoff(policy, 2))
+		assert.Equal(t, 8*time.Second, Backoff(policy, 4))
+		assert.Equal(t, time.Minute, Backoff(policy, 20))
+	})
+
+	t.Run("fixed", func(t *testing.T) {
+		t.Parallel()
+		policy := config.RetryConfig{Delay: time.Second, MaxDelay: time.Minute, Backoff: config.BackoffFixed}
+		assert.Equal(t, time.Second, Backoff(policy, 1))
+		assert.Equal(t, time.Second, Backoff(policy, 5))
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		t.Parallel()
+		for range 100 {
+			delay := jitter(10*time.Second, 0.2)
+			assert.GreaterOrEqual(t, delay, 8*time.Second)
+			assert.LessOrEqual(t, delay, 12*time.Second)
+		}
+		assert.Equal(t, 10*time.Second, jitter(10*time.Second, 0))
+	})
+}
+
+// webhookTarget posts to a test server through a response-recording client.
+type webhookTarget struct {
+	url    string
+	client *http.Client
+}
+
+func (t webhookTarget) Send(ctx context.Context, _ kit.Payload) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func TestQueueWebhookStatus(t *testing.T) {
+	t.Parallel()
+
+	serve := func(t *testing.T, status int, header http.Header) kit.Target {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			maps.Copy(w.Header(), header)
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(srv.Close)
+		return webhookTarget{url: srv.URL, client: notify.RecordResponses(&http.Client{})}
+	}
+
+	t.Run("never retries client errors", func(t *testing.T) {
+		t.Parallel()
+		q, _ := newQueue(t, "", serve(t, http.StatusBadRequest, nil), config.RetryConfig{Count: utils.ToPtr(3), Delay: time.Millisecond})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		q.Start(ctx)
+		_, err := q.Enqueue(ctx, event())
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool { return len(q.Failed()) == 1 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 1, q.Failed()[0].Attempts)
+		assert.Equal(t, http.StatusBadRequest, q.Failed()[0].LastStatus)
+	})
+
+	t.Run("honors Retry-After on 429", func(t *testing.T) {
+		t.Parallel()
+		target := serve(t, http.StatusTooManyRequests, http.Header{"Retry-After": {"120"}})
+		q, store := newQueue(t, "", target, config.RetryConfig{Count: utils.ToPtr(3), Delay: time.Millisecond})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		q.Start(ctx)
+		start := time.Now()
+		_, err := q.Enqueue(ctx, event())
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			return q.pending[0].Attempts == 1
+		}, time.Second, 5*time.Millisecond)
+		q.mu.Lock()
+		next := q.pending[0].NextAttempt
+		q.mu.Unlock()
+		assert.WithinDuration(t, start.Add(2*time.Minute), next, 5*time.Second)
+
+		assert.Eventually(t, func() bool { return len(store.List()) == 1 }, time.Second, 5*time.Millisecond)
+		ev := store.List()[0]
+		assert.Equal(t, history.EventNotificationRetried.String(), ev.Type)
+		assert.Equal(t, http.StatusTooManyRequests, ev.Fields["status_code"])
+		assert.Equal(t, "2m0s", ev.Fields["retry_in"])
+	})
+}
@@ -0,0 +1,299 @@
+// Package overlay persists heartbeat and receiver changes made through the
+// API and applies them by reloading the merged config.
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/containeroo/heartbeats/internal/config"
+)
+
+var (
+	// ErrInvalid marks a change that would produce an invalid config.
+	ErrInvalid = errors.New("invalid change")
+	// ErrNotFound marks a change to an unknown entry.
+	ErrNotFound = errors.New("not found")
+	// ErrExists marks a create of an existing entry.
+	ErrExists = errors.New("already exists")
+	// ErrLimit marks an auto-provisioned heartbeat beyond the configured cap.
+	ErrLimit = errors.New("auto-provision limit reached")
+
+	// errUnchanged ends an edit without persisting or applying anything.
+	errUnchanged = errors.New("unchanged")
+)
+
+// Store holds the overlay and writes it to an optional file.
+type Store struct {
+	path string
+
+	editMu sync.Mutex // Serializes edits, including the apply step.
+
+	mu       sync.RWMutex
+	data     config.Overlay
+	cfg      *config.Config             // Last applied config, used to validate edits.
+	validate func(*config.Config) error // Extra checks on the merged config, e.g. building receivers.
+	apply    func() error               // Applies the overlay, usually a config reload.
+}
+
+// NewStore loads the overlay from path. An empty path keeps changes in memory;
+// a missing file starts an empty overlay.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read overlay: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &s.data); err != nil {
+		return nil, fmt.Errorf("parse overlay %q: %w", path, err)
+	}
+	return s, nil
+}
+
+// Overlay returns a copy of the current overlay.
+func (s *Store) Overlay() config.Overlay {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return clone(s.data)
+}
+
+// SetConfig records the applied config that edits are validated against.
+func (s *Store) SetConfig(cfg *config.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+}
+
+// SetApplyFn sets the function that applies the overlay after an edit.
+func (s *Store) SetApplyFn(fn func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apply = fn
+}
+
+// SetValidateFn sets an extra check run on the merged config before an edit
+// is persisted.
+func (s *Store) SetValidateFn(fn func(*config.Config) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validate = fn
+}
+
+// Receiver returns the applied definition of a receiver.
+func (s *Store) Receiver(name string) (config.ReceiverConfig, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.cfg == nil {
+		return config.ReceiverConfig{}, false
+	}
+	rcv, ok := s.cfg.Receivers[name]
+	return rcv, ok
+}
+
+// CreateHeartbeat adds a heartbeat that must not exist yet.
+func (s *Store) CreateHeartbeat(id string, hb config.HeartbeatConfig) error {
+	return s.edit(func(cfg *config.Config, next *config.Overlay) error {
+		if _, ok := cfg.Heartbeats[id]; ok {
+			return fmt.Errorf("heartbeat %q %w", id, ErrExists)
+		}
+		next.PutHeartbeat(id, hb)
+		return nil
+	})
+}
+
+// PutHeartbeat creates or replaces a heartbeat and reports whether it was created.
+func (s *Store) PutHeartbeat(id string, hb config.HeartbeatConfig) (bool, error) {
+	var created bool
+	err := s.edit(func(cfg *config.Config, next *config.Overlay) error {
+		_, exists := cfg.Heartbeats[id]
+		created = !exists
+		next.PutHeartbeat(id, hb)
+		return nil
+	})
+	return created, err
+}
+
+// DeleteHeartbeat removes a heartbeat, including one defined in the config file.
+func (s *Store) DeleteHeartbeat(id string) error {
+	return s.edit(func(cfg *config.Config, next *config.Overlay) error {
+		if _, ok := cfg.Heartbeats[id]; !ok {
+			return fmt.Errorf("heartbeat %q %w", id, ErrNotFound)
+		}
+		next.DeleteHeartbeat(id)
+		return nil
+	})
+}
+
+// ProvisionHeartbeat creates an auto-provisioned heartbeat unless id already
+// exists. At most limit heartbeats are auto-provisioned.
+func (s *Store) ProvisionHeartbeat(id string, hb config.HeartbeatConfig, limit int) error {
+	return s.edit(func(cfg *config.Config, next *config.Overlay) error {
+		if _, ok := cfg.Heartbeats[id]; ok {
+			// Provisioned by a concurrent ping.
+			return errUnchanged
+		}
+		if len(next.Provisioned) >= limit {
+			return fmt.Errorf("%w: %d heartbeats", ErrLimit, limit)
+		}
+		next.PutHeartbeat(id, hb)
+		next.Provisioned = append(next.Provisioned, id)
+		return nil
+	})
+}
+
+// CreateReceiver adds a receiver that must not exist yet.
+func (s *Store) CreateReceiver(name string, rcv config.ReceiverConfig) error {
+	return s.edit(func(cfg *config.Config, next *config.Overlay) error {
+		if _, ok := cfg.Receivers[name]; ok {
+			return fmt.Errorf("receiver %q %w", name, ErrExists)
+		}
+		if err := rcv.RestoreSecrets(config.ReceiverConfig{}); err != nil {
+			return fmt.Errorf("%w: receiver %q: %w", ErrInvalid, name, err)
+		}
+		next.PutReceiver(name, rcv)
+		return nil
+	})
+}
+
+// PutReceiver creates or replaces a receiver and reports whether it was
+// created. Redacted secrets keep the values of the matching previous target;
+// redacted values without one are rejected as invalid.
+func (s *Store) PutReceiver(name string, rcv config.ReceiverConfig) (bool, error) {
+	var created bool
+	err := s.edit(func(cfg *config.Config, next *config.Overlay) error {
+		prev, exists := cfg.Receivers[name]
+		created = !exists
+		if err := rcv.RestoreSecrets(prev); err != nil {
+			return fmt.Errorf("%w: receiver %q: %w", ErrInvalid, name, err)
+		}
+		next.PutReceiver(name, rcv)
+		return nil
+	})
+	return created, err
+}
+
+// DeleteReceiver removes a receiver. It fails while heartbeats or digests
+// still reference it.
+func (s *Store) DeleteReceiver(name string) error {
+	return s.edit(func(cfg *config.Config, next *config.Overlay) error {
+		if _, ok := cfg.Receivers[name]; !ok {
+			return fmt.Errorf("receiver %q %w", name, ErrNotFound)
+		}
+		next.DeleteReceiver(name)
+		return nil
+	})
+}
+
+// edit changes a copy of the overlay, validates the merged result, persists
+// it, and applies it. The previous overlay is restored when applying fails.
+func (s *Store) edit(fn func(cfg *config.Config, next *config.Overlay) error) error {
+	s.editMu.Lock()
+	defer s.editMu.Unlock()
+
+	s.mu.RLock()
+	cfg, prev, validate, apply := s.cfg, clone(s.data), s.validate, s.apply
+	s.mu.RUnlock()
+	if cfg == nil {
+		return errors.New("config not loaded")
+	}
+
+	next := clone(prev)
+	if err := fn(cfg, &next); err != nil {
+		if errors.Is(err, errUnchanged) {
+			return nil
+		}
+		return err
+	}
+
+	// cfg already contains the previous overlay; merging next on top yields
+	// the config the reload will produce.
+	candidate := *cfg
+	candidate.Heartbeats = maps.Clone(cfg.Heartbeats)
+	candidate.Receivers = maps.Clone(cfg.Receivers)
+	next.Apply(&candidate)
+	if err := candidate.Resolve(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	if err := candidate.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	if validate != nil {
+		if err := validate(&candidate); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+	}
+
+	if err := s.save(next); err != nil {
+		return err
+	}
+	s.set(next)
+	if apply == nil {
+		return nil
+	}
+	if err := apply(); err != nil {
+		s.set(prev)
+		if saveErr := s.save(prev); saveErr != nil {
+			return errors.Join(fmt.Errorf("apply: %w", err), saveErr)
+		}
+		return fmt.Errorf("apply: %w", err)
+	}
+	return nil
+}
+
+// set replaces the in-memory overlay.
+func (s *Store) set(o config.Overlay) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = o
+}
+
+// save writes the overlay atomically when a path is configured.
+func (s *Store) save(o config.Overlay) error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := yaml.Marshal(o)
+	if err != nil {
+		return fmt.Errorf("encode overlay: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".overlay-*.yaml")
+	if err != nil {
+		return fmt.Errorf("write overlay: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write overlay: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write overlay: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write overlay: %w", err)
+	}
+	return nil
+}
+
+// clone copies the overlay maps and slices so edits do not leak.
+func clone(o config.Overlay) config.Overlay {
+	return config.Overlay{
+		Heartbeats:        maps.Clone(o.Heartbeats),
+		DeletedHeartbeats: slices.Clone(o.DeletedHeartbeats),
+		Receivers:         maps.Clone(o.Receivers),
+		DeletedReceivers:  slices.Clone(o.DeletedReceivers),
+		Provisioned:       slices.Clone(o.Provisioned),
+	}
+}
@@ -0,0 +1,161 @@
+package overlay
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containeroo/heartbeats/internal/config"
+)
+
+func baseConfig() *config.Config {
+	return &config.Config{
+		Receivers: map[string]config.ReceiverConfig{
+			"ops": {Webhooks: []config.WebhookConfig{{URL: "https://example.com"}}},
+		},
+		Heartbeats: map[string]config.HeartbeatConfig{
+			"api": {Interval: time.Minute, LateAfter: time.Minute, Receivers: []string{"ops"}},
+		},
+	}
+}
+
+func heartbeat(interval time.Duration) config.HeartbeatConfig {
+	return config.HeartbeatConfig{Interval: interval, LateAfter: time.Minute, Receivers: []string{"ops"}}
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("persists and applies edits", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "overlay.yaml")
+		s, err := NewStore(path)
+		require.NoError(t, err)
+		s.SetConfig(baseConfig())
+		applied := 0
+		s.SetApplyFn(func() error { applied++; return nil })
+
+		require.NoError(t, s.CreateHeartbeat("backup", heartbeat(time.Hour)))
+		created, err := s.PutHeartbeat("api", heartbeat(2*time.Minute))
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, 2, applied)
+
+		reopened, err := NewStore(path)
+		require.NoError(t, err)
+		o := reopened.Overlay()
+		assert.Equal(t, time.Hour, o.Heartbeats["backup"].Interval)
+		assert.Equal(t, 2*time.Minute, o.Heartbeats["api"].Interval)
+	})
+
+	t.Run("rejects invalid changes", func(t *testing.T) {
+		t.Parallel()
+		s, err := NewStore("")
+		require.NoError(t, err)
+		s.SetConfig(baseConfig())
+
+		err = s.CreateHeartbeat("api", heartbeat(time.Hour))
+		assert.ErrorIs(t, err, ErrExists)
+
+		err = s.CreateHeartbeat("backup", config.HeartbeatConfig{Interval: time.Hour, Receivers: []string{"ops"}})
+		assert.ErrorIs(t, err, ErrInvalid)
+		assert.Contains(t, err.Error(), `heartbeat "backup" late_after must be > 0`)
+
+		err = s.DeleteHeartbeat("ghost")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		err = s.DeleteHeartbeat("api")
+		assert.ErrorIs(t, err, ErrInvalid, "the last heartbeat cannot be removed")
+		assert.Empty(t, s.Overlay().Heartbeats)
+	})
+
+	t.Run("restores the overlay when applying fails", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "overlay.yaml")
+		s, err := NewStore(path)
+		require.NoError(t, err)
+		s.SetConfig(baseConfig())
+		s.SetApplyFn(func() error { return errors.New("boom") })
+
+		err = s.CreateHeartbeat("backup", heartbeat(time.Hour))
+		require.EqualError(t, err, "apply: boom")
+		assert.Empty(t, s.Overlay().Heartbeats)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "backup")
+	})
+
+	t.Run("receivers", func(t *testing.T) {
+		t.Parallel()
+		s, err := NewStore("")
+		require.NoError(t, err)
+		cfg := baseConfig()
+		cfg.Receivers["mail"] = config.ReceiverConfig{Emails: []config.EmailConfig{
+			{Host: "smtp", From: "a@example.com", To: []string{"b@example.com"}, Pass: "hunter2"},
+		}}
+		s.SetConfig(cfg)
+		var validated []string
+		s.SetValidateFn(func(cfg *config.Config) error {
+			for name := range cfg.Receivers {
+				validated = append(validated, name)
+			}
+			return nil
+		})
+
+		err = s.DeleteReceiver("ops")
+		assert.ErrorIs(t, err, ErrInvalid, "ops is still referenced by a heartbeat")
+
+		created, err := s.PutReceiver("mail", config.ReceiverConfig{Emails: []config.EmailConfig{
+			{Host: "smtp", From: "a@example.com", To: []string{"c@example.com"}, Pass: config.RedactedSecret},
+		}})
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, "hunter2", s.Overlay().Receivers["mail"].Emails[0].Pass)
+
+		_, err = s.PutReceiver("mail", config.ReceiverConfig{Emails: []config.EmailConfig{
+			{Host: "smtp2", From: "a@example.com", To: []string{"c@example.com"}, Pass: config.RedactedSecret},
+		}})
+		assert.ErrorIs(t, err, ErrInvalid, "a redacted password is not moved to another host")
+		assert.Contains(t, validated, "mail")
+
+		require.NoError(t, s.DeleteReceiver("mail"))
+		assert.Equal(t, []string{"mail"}, s.Overlay().DeletedReceivers)
+	})
+
+	t.Run("provisioning is capped", func(t *testing.T) {
+		t.Parallel()
+		s, err := NewStore("")
+		require.NoError(t, err)
+		s.SetConfig(baseConfig())
+
+		require.NoError(t, s.ProvisionHeartbeat("cron-a", heartbeat(time.Hour), 2))
+		require.NoError(t, s.ProvisionHeartbeat("api", heartbeat(time.Hour), 2), "existing ids are left alone")
+		require.NoError(t, s.ProvisionHeartbeat("cron-b", heartbeat(time.Hour), 2))
+		err = s.ProvisionHeartbeat("cron-c", heartbeat(time.Hour), 2)
+		require.ErrorIs(t, err, ErrLimit)
+		assert.Equal(t, []string{"cron-a", "cron-b"}, s.Overlay().Provisioned)
+		assert.NotContains(t, s.Overlay().Heartbeats, "api")
+
+		// The store config is only refreshed by a reload; emulate it so the delete sees cron-a.
+		cfg := baseConfig()
+		s.Overlay().Apply(cfg)
+		s.SetConfig(cfg)
+		require.NoError(t, s.DeleteHeartbeat("cron-a"))
+		require.NoError(t, s.ProvisionHeartbeat("cron-c", heartbeat(time.Hour), 2))
+		assert.Equal(t, []string{"cron-b", "cron-c"}, s.Overlay().Provisioned)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "overlay.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("heartbeats: ["), 0o600))
+		_, err := NewStore(path)
+		require.Error(t, err)
+	})
+}
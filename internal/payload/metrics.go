@@ -0,0 +1,73 @@
+package payload
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/containeroo/heartbeats/internal/config"
+)
+
+// Sample is a numeric value extracted from a payload.
+type Sample struct {
+	Name  string  // Metric name from config.
+	Value float64 // Extracted value.
+}
+
+// Extractor pulls configured numeric fields out of JSON payloads.
+type Extractor struct {
+	metrics []config.PayloadMetric
+}
+
+// NewExtractor builds an Extractor. No metrics yield a nil Extractor,
+// which extracts nothing.
+func NewExtractor(metrics []config.PayloadMetric) *Extractor {
+	if len(metrics) == 0 {
+		return nil
+	}
+	return &Extractor{metrics: append([]config.PayloadMetric(nil), metrics...)}
+}
+
+// Extract returns the numeric samples found in body and an error describing
+// the first threshold violation. Non-JSON payloads, missing paths and
+// non-numeric values are skipped.
+func (e *Extractor) Extract(body string) ([]Sample, error) {
+	if e == nil {
+		return nil, nil
+	}
+	var doc any
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, nil
+	}
+
+	var (
+		samples   []Sample
+		violation error
+	)
+	for _, metric := range e.metrics {
+		raw, ok := Lookup(doc, metric.Path)
+		if !ok {
+			continue
+		}
+		value, ok := Number(raw)
+		if !ok {
+			continue
+		}
+		samples = append(samples, Sample{Name: metric.Name, Value: value})
+		if violation != nil {
+			continue
+		}
+		if metric.Min != nil && value < *metric.Min {
+			violation = fmt.Errorf("%s %s is below min %s", metric.Name, formatFloat(value), formatFloat(*metric.Min))
+		}
+		if metric.Max != nil && value > *metric.Max {
+			violation = fmt.Errorf("%s %s is above max %s", metric.Name, formatFloat(value), formatFloat(*metric.Max))
+		}
+	}
+	return samples, violation
+}
+
+// formatFloat renders a float without trailing zeros.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,63 @@
+package payload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/utils"
+)
+
+func TestExtractorExtract(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil extractor", func(t *testing.T) {
+		t.Parallel()
+		samples, err := NewExtractor(nil).Extract(`{"bytes":1}`)
+		require.NoError(t, err)
+		assert.Empty(t, samples)
+	})
+
+	extractor := NewExtractor([]config.PayloadMetric{
+		{Name: "bytes", Path: "bytes", Min: utils.ToPtr(1024.0)},
+		{Name: "duration", Path: "stats.duration", Max: utils.ToPtr(60.0)},
+	})
+
+	t.Run("extracts values within thresholds", func(t *testing.T) {
+		t.Parallel()
+		samples, err := extractor.Extract(`{"bytes":2048,"stats":{"duration":"45"}}`)
+		require.NoError(t, err)
+		assert.Equal(t, []Sample{{Name: "bytes", Value: 2048}, {Name: "duration", Value: 45}}, samples)
+	})
+
+	t.Run("below min", func(t *testing.T) {
+		t.Parallel()
+		samples, err := extractor.Extract(`{"bytes":123,"stats":{"duration":45}}`)
+		require.Error(t, err)
+		assert.Equal(t, "bytes 123 is below min 1024", err.Error())
+		assert.Len(t, samples, 2)
+	})
+
+	t.Run("above max", func(t *testing.T) {
+		t.Parallel()
+		_, err := extractor.Extract(`{"bytes":2048,"stats":{"duration":61.5}}`)
+		require.Error(t, err)
+		assert.Equal(t, "duration 61.5 is above max 60", err.Error())
+	})
+
+	t.Run("skips missing and non-numeric values", func(t *testing.T) {
+		t.Parallel()
+		samples, err := extractor.Extract(`{"bytes":"lots"}`)
+		require.NoError(t, err)
+		assert.Empty(t, samples)
+	})
+
+	t.Run("ignores non-JSON payloads", func(t *testing.T) {
+		t.Parallel()
+		samples, err := extractor.Extract("done")
+		require.NoError(t, err)
+		assert.Empty(t, samples)
+	})
+}
@@ -0,0 +1,78 @@
+package payload
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Lookup resolves a dotted JSON path such as "result.items[0].status"
+// against a decoded JSON document. A leading "$." is ignored.
+func Lookup(doc any, path string) (any, bool) {
+	segments, err := splitPath(path)
+	if err != nil {
+		return nil, false
+	}
+	current := doc
+	for _, seg := range segments {
+		switch node := current.(type) {
+		case map[string]any:
+			if seg.index >= 0 {
+				return nil, false
+			}
+			value, ok := node[seg.key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			if seg.index < 0 || seg.index >= len(node) {
+				return nil, false
+			}
+			current = node[seg.index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// pathSegment is either an object key or an array index (index >= 0).
+type pathSegment struct {
+	key   string
+	index int
+}
+
+// splitPath tokenizes a JSON path into keys and indexes.
+func splitPath(path string) ([]pathSegment, error) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return nil, nil
+	}
+
+	var out []pathSegment
+	for part := range strings.SplitSeq(path, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key != "" {
+			out = append(out, pathSegment{key: key, index: -1})
+		}
+		for rest != "" {
+			idx, tail, ok := strings.Cut(rest, "]")
+			if !ok {
+				return nil, fmt.Errorf("unterminated index in %q", part)
+			}
+			n, err := strconv.Atoi(idx)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid index %q in %q", idx, part)
+			}
+			out = append(out, pathSegment{index: n})
+			rest = strings.TrimPrefix(tail, "[")
+		}
+		if key == "" && !strings.Contains(part, "[") {
+			return nil, fmt.Errorf("empty segment in %q", path)
+		}
+	}
+	return out, nil
+}
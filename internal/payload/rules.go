@@ -0,0 +1,176 @@
+package payload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/containeroo/heartbeats/internal/config"
+)
+
+// Rules are compiled payload validation rules for a heartbeat.
+type Rules struct {
+	maxSize      int
+	match        *regexp.Regexp
+	requiredKeys []string
+	assertions   []assertion
+}
+
+// assertion is a compiled JSON path assertion.
+type assertion struct {
+	path   string
+	equals any
+	match  *regexp.Regexp
+	exists bool
+}
+
+// NewRules compiles payload rules from config. A nil config yields nil rules,
+// which accept every payload.
+func NewRules(cfg *config.PayloadRules) (*Rules, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	rules := &Rules{
+		maxSize:      cfg.MaxSize,
+		requiredKeys: append([]string(nil), cfg.RequiredKeys...),
+	}
+	if cfg.Match != "" {
+		re, err := regexp.Compile(cfg.Match)
+		if err != nil {
+			return nil, fmt.Errorf("compile match: %w", err)
+		}
+		rules.match = re
+	}
+	for idx, a := range cfg.Assertions {
+		compiled := assertion{
+			path:   a.Path,
+			equals: a.Equals,
+			exists: a.Exists == nil || *a.Exists,
+		}
+		if a.Match != "" {
+			re, err := regexp.Compile(a.Match)
+			if err != nil {
+				return nil, fmt.Errorf("compile assertions[%d] match: %w", idx, err)
+			}
+			compiled.match = re
+		}
+		rules.assertions = append(rules.assertions, compiled)
+	}
+	return rules, nil
+}
+
+// Check validates a payload and returns an error describing the first failed rule.
+func (r *Rules) Check(body string) error {
+	if r == nil {
+		return nil
+	}
+	if r.maxSize > 0 && len(body) > r.maxSize {
+		return fmt.Errorf("payload size %d exceeds max_size %d", len(body), r.maxSize)
+	}
+	if r.match != nil && !r.match.MatchString(body) {
+		return fmt.Errorf("payload does not match %q", r.match.String())
+	}
+	if len(r.requiredKeys) == 0 && len(r.assertions) == 0 {
+		return nil
+	}
+
+	var doc any
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return errors.New("payload is not valid JSON")
+	}
+	for _, key := range r.requiredKeys {
+		if _, ok := Lookup(doc, key); !ok {
+			return fmt.Errorf("payload is missing required key %q", key)
+		}
+	}
+	for _, a := range r.assertions {
+		if err := a.check(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check evaluates a single assertion against a decoded document.
+func (a assertion) check(doc any) error {
+	value, found := Lookup(doc, a.path)
+	if !a.exists {
+		if found {
+			return fmt.Errorf("payload key %q must not exist", a.path)
+		}
+		return nil
+	}
+	if !found {
+		return fmt.Errorf("payload is missing key %q", a.path)
+	}
+	if a.equals != nil && !valuesEqual(value, a.equals) {
+		return fmt.Errorf("payload key %q is %s, expected %s", a.path, formatValue(value), formatValue(a.equals))
+	}
+	if a.match != nil && !a.match.MatchString(formatValue(value)) {
+		return fmt.Errorf("payload key %q value %s does not match %q", a.path, formatValue(value), a.match.String())
+	}
+	return nil
+}
+
+// valuesEqual compares a decoded JSON value with a YAML-configured value,
+// treating all numeric types as float64. Strings are compared as strings,
+// so "1.0" does not equal "1" and numbers never equal numeric strings.
+func valuesEqual(actual, expected any) bool {
+	a, aNumber := nonStringNumber(actual)
+	e, eNumber := nonStringNumber(expected)
+	if aNumber && eNumber {
+		return a == e
+	}
+	return reflect.DeepEqual(actual, expected)
+}
+
+// nonStringNumber is Number without the numeric string conversion.
+func nonStringNumber(value any) (float64, bool) {
+	if _, ok := value.(string); ok {
+		return 0, false
+	}
+	return Number(value)
+}
+
+// Number converts numeric JSON/YAML values (and numeric strings) to float64.
+func Number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// formatValue renders a value for messages and regex matching.
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,118 @@
+package payload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containeroo/heartbeats/internal/config"
+	"github.com/containeroo/heartbeats/internal/utils"
+)
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	doc := map[string]any{
+		"status": "ok",
+		"result": map[string]any{
+			"items": []any{
+				map[string]any{"name": "db"},
+			},
+		},
+	}
+
+	tests := []struct {
+		path  string
+		value any
+		found bool
+	}{
+		{"status", "ok", true},
+		{"$.status", "ok", true},
+		{"result.items[0].name", "db", true},
+		{"result.items[1].name", nil, false},
+		{"result.missing", nil, false},
+		{"status.nested", nil, false},
+		{"result.items[x]", nil, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			t.Parallel()
+			value, found := Lookup(doc, tc.path)
+			assert.Equal(t, tc.found, found)
+			assert.Equal(t, tc.value, value)
+		})
+	}
+}
+
+func TestRulesCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil rules accept everything", func(t *testing.T) {
+		t.Parallel()
+		rules, err := NewRules(nil)
+		require.NoError(t, err)
+		assert.NoError(t, rules.Check("anything"))
+	})
+
+	rules, err := NewRules(&config.PayloadRules{
+		MaxSize:      128,
+		Match:        `"status"`,
+		RequiredKeys: []string{"status", "stats.bytes"},
+		Assertions: []config.PayloadAssertion{
+			{Path: "status", Equals: "success"},
+			{Path: "stats.bytes", Equals: 42},
+			{Path: "host", Match: `^db-\d+$`},
+			{Path: "error", Exists: utils.ToPtr(false)},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		body   string
+		reason string
+	}{
+		{"valid", `{"status":"success","stats":{"bytes":42},"host":"db-1"}`, ""},
+		{"too large", `{"status":"success","padding":"` + string(make([]byte, 128)) + `"}`, "exceeds max_size"},
+		{"regex mismatch", `{"state":"success"}`, "does not match"},
+		{"invalid json", `"status" nope`, "not valid JSON"},
+		{"missing key", `{"status":"success","host":"db-1"}`, `missing required key "stats.bytes"`},
+		{"wrong value", `{"status":"failed","stats":{"bytes":42},"host":"db-1"}`, `"status" is failed, expected success`},
+		{"wrong number", `{"status":"success","stats":{"bytes":1},"host":"db-1"}`, `"stats.bytes" is 1, expected 42`},
+		{"value regex mismatch", `{"status":"success","stats":{"bytes":42},"host":"web-1"}`, `"host" value web-1 does not match`},
+		{"forbidden key", `{"status":"success","stats":{"bytes":42},"host":"db-1","error":"boom"}`, `"error" must not exist`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := rules.Check(tc.body)
+			if tc.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.reason)
+		})
+	}
+
+	t.Run("strings are not compared as numbers", func(t *testing.T) {
+		t.Parallel()
+		rules, err := NewRules(&config.PayloadRules{Assertions: []config.PayloadAssertion{
+			{Path: "version", Equals: "1.0"},
+			{Path: "code", Equals: 0},
+		}})
+		require.NoError(t, err)
+
+		assert.NoError(t, rules.Check(`{"version":"1.0","code":0.0}`))
+		assert.ErrorContains(t, rules.Check(`{"version":"1","code":0}`), `"version" is 1, expected 1.0`)
+		assert.ErrorContains(t, rules.Check(`{"version":1.0,"code":0}`), `"version" is 1, expected 1.0`)
+		assert.ErrorContains(t, rules.Check(`{"version":"1.0","code":"0"}`), `"code" is 0, expected 0`)
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewRules(&config.PayloadRules{Match: "("})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/containeroo/heartbeats/internal/auth"
+)
+
+// AuthMiddleware requires a caller with at least the given role.
+// It passes everything through when authn is nil or has no methods configured.
+func AuthMiddleware(authn *auth.Authenticator, role auth.Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authn.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		id, ok := authn.Authenticate(r)
+		if !ok {
+			if oidc := authn.OIDC(); oidc != nil {
+				loginURL := oidc.LoginURL(r.RequestURI)
+				if r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html") {
+					http.Redirect(w, r, loginURL, http.StatusFound)
+					return
+				}
+				// Lets the dashboard send the browser to the login page.
+				w.Header().Set("X-Login-URL", loginURL)
+			}
+			w.Header().Set("WWW-Authenticate", string(authn.Challenge()))
+			writeAuthError(w, http.StatusUnauthorized, "authentication required")
+			return
+		}
+		if id.Role < role {
+			writeAuthError(w, http.StatusForbidden, "role "+role.String()+" required")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), id)))
+	})
+}
+
+// writeAuthError writes a JSON error body matching the API error format.
+func writeAuthError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -0,0 +1,172 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/containeroo/heartbeats/internal/auth"
+	"github.com/containeroo/heartbeats/internal/auth/oidctest"
+	"github.com/containeroo/heartbeats/internal/config"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	t.Parallel()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := auth.FromContext(r.Context())
+		_, _ = w.Write([]byte(id.Name))
+	})
+
+	authn, err := auth.New(config.AuthConfig{
+		Tokens: []config.TokenAuthConfig{
+			{Name: "reader", Token: "read-token"},
+			{Name: "ops", Token: "admin-token", Role: "admin"},
+		},
+	})
+	require.NoError(t, err)
+
+	serve := func(authn *auth.Authenticator, role auth.Role, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/-/reload", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		AuthMiddleware(authn, role, ok).ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("disabled passes through", func(t *testing.T) {
+		t.Parallel()
+		rec := serve(nil, auth.RoleAdmin, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("missing credentials", func(t *testing.T) {
+		t.Parallel()
+		rec := serve(authn, auth.RoleViewer, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, string(auth.ChallengeBearer), rec.Header().Get("WWW-Authenticate"))
+		assert.JSONEq(t, `{"error":"authentication required"}`, rec.Body.String())
+	})
+
+	t.Run("viewer can read", func(t *testing.T) {
+		t.Parallel()
+		rec := serve(authn, auth.RoleViewer, "read-token")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "reader", rec.Body.String())
+	})
+
+	t.Run("viewer cannot reload", func(t *testing.T) {
+		t.Parallel()
+		rec := serve(authn, auth.RoleAdmin, "read-token")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("admin can reload", func(t *testing.T) {
+		t.Parallel()
+		rec := serve(authn, auth.RoleAdmin, "admin-token")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestAuthMiddlewareOIDC(t *testing.T) {
+	t.Parallel()
+
+	login := func(t *testing.T, groups []string) (*http.Client, *httptest.Server) {
+		t.Helper()
+
+		idp := oidctest.NewServer(oidctest.User{Subject: "u-1", Username: "alice", Groups: groups})
+		t.Cleanup(idp.Close)
+
+		var handler http.Handler
+		app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler.ServeHTTP(w, r)
+		}))
+		t.Cleanup(app.Close)
+
+		authn, err := auth.New(config.AuthConfig{OIDC: &config.OIDCConfig{
+			Issuer:      idp.Issuer(),
+			ClientID:    "heartbeats",
+			RedirectURL: app.URL + "/auth/callback",
+			Roles:       map[string][]string{"admin": {"hb-admins"}, "viewer": {"staff"}},
+		}})
+		require.NoError(t, err)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /auth/login", authn.Login())
+		mux.HandleFunc("GET /auth/callback", authn.Callback())
+		mux.HandleFunc("POST /auth/logout", authn.Logout())
+		mux.Handle("/", AuthMiddleware(authn, auth.RoleViewer, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, _ := auth.FromContext(r.Context())
+			_, _ = w.Write([]byte(id.Name + " " + id.Role.String()))
+		})))
+		handler = mux
+
+		jar, err := cookiejar.New(nil)
+		require.NoError(t, err)
+		return &http.Client{Jar: jar}, app
+	}
+
+	do := func(t *testing.T, client *http.Client, method, target, accept string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, target, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", accept)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+	get := func(t *testing.T, client *http.Client, target, accept string) *http.Response {
+		t.Helper()
+		return do(t, client, http.MethodGet, target, accept)
+	}
+
+	t.Run("browser login maps groups to roles", func(t *testing.T) {
+		t.Parallel()
+		client, app := login(t, []string{"staff", "hb-admins"})
+
+		resp := get(t, client, app.URL+"/heartbeats?view=list", "text/html")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "alice admin", string(body))
+		assert.Equal(t, "/heartbeats", resp.Request.URL.Path, "returns to the original page")
+
+		resp = get(t, client, app.URL+"/api/status", "application/json")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// Stop at the logout redirect; following it would log in again at the stub provider.
+		noFollow := *client
+		noFollow.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+		get(t, &noFollow, app.URL+"/auth/logout", "text/html")
+		resp = get(t, client, app.URL+"/api/status", "application/json")
+		require.Equal(t, http.StatusOK, resp.StatusCode, "GET does not log out")
+		resp = do(t, &noFollow, http.MethodPost, app.URL+"/auth/logout", "text/html")
+		require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+		resp = get(t, client, app.URL+"/api/status", "application/json")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("api requests get a login hint", func(t *testing.T) {
+		t.Parallel()
+		client, app := login(t, []string{"staff"})
+
+		resp := get(t, client, app.URL+"/api/status", "application/json")
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "/auth/login?redirect=%2Fapi%2Fstatus", resp.Header.Get("X-Login-URL"))
+	})
+
+	t.Run("users without a mapped group are denied", func(t *testing.T) {
+		t.Parallel()
+		client, app := login(t, []string{"contractors"})
+
+		resp := get(t, client, app.URL+"/", "text/html")
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
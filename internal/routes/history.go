@@ -29,7 +29,7 @@ func HistoryMiddleware(recorder history.Recorder, next http.Handler) http.Handle
 			Message: "http_access",
 			Fields: map[string]any{
 				"method":      r.Method,
-				"url_path":    r.URL.Path,
+				"url_path":    redactPingToken(r.URL.Path),
 				"status_code": rec.statusCode,
 				"remote_addr": r.RemoteAddr,
 				"user_agent":  r.UserAgent(),
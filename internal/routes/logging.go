@@ -26,7 +26,7 @@ func LoggingMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
 
 		logger.Debug("HTTP request",
 			"method", r.Method,
-			"url_path", r.URL.Path,
+			"url_path", redactPingToken(r.URL.Path),
 			"status_code", rec.statusCode,
 			"remote_addr", r.RemoteAddr,
 			"user_agent", r.UserAgent(),
@@ -77,6 +77,18 @@ func (rw *responseRecorder) Unwrap() http.ResponseWriter {
 	return rw.ResponseWriter
 }
 
+// redactPingToken masks the token segment of /heartbeat/{id}/{token} paths.
+func redactPingToken(path string) string {
+	parts := strings.Split(path, "/")
+	for i := 0; i+2 < len(parts); i++ {
+		if parts[i] == "heartbeat" && parts[i+1] != "" && parts[i+2] != "" && parts[i+2] != "payloads" {
+			parts[i+2] = "REDACTED"
+			return strings.Join(parts, "/")
+		}
+	}
+	return path
+}
+
 func extractHeartbeatID(path string) string {
 	trimmed := strings.Trim(path, "/")
 	if trimmed == "" {
@@ -64,4 +64,17 @@ func TestLoggingMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusOK, rec.Code)
 		assert.Contains(t, logs.String(), "status_code=200")
 	})
+	t.Run("redacts ping tokens in the path", func(t *testing.T) {
+		t.Parallel()
+
+		var logs strings.Builder
+		logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+		req := httptest.NewRequest(http.MethodPost, "/api/heartbeat/backup/s3cret", nil)
+		LoggingMiddleware(logger, handler).ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Contains(t, logs.String(), "url_path=/api/heartbeat/backup/REDACTED")
+		assert.NotContains(t, logs.String(), "s3cret")
+	})
 }
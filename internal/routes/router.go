@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/containeroo/heartbeats/internal/auth"
 	"github.com/containeroo/heartbeats/internal/handler"
 	"github.com/containeroo/heartbeats/internal/logging"
 	"github.com/containeroo/httpprefix"
@@ -37,29 +38,56 @@ func NewRouter(
 	mux.Handle("GET /favicon-64x64.png", spaFiles)
 	mux.Handle("GET /apple-touch-icon.png", spaFiles)
 
+	// Heartbeat pings, probes and metrics stay reachable without dashboard credentials.
+	authn := api.Authenticator()
+	viewer := func(h http.Handler) http.Handler { return AuthMiddleware(authn, auth.RoleViewer, h) }
+	operator := func(h http.Handler) http.Handler { return AuthMiddleware(authn, auth.RoleOperator, h) }
+	admin := func(h http.Handler) http.Handler { return AuthMiddleware(authn, auth.RoleAdmin, h) }
+
+	mux.HandleFunc("GET /auth/login", authn.Login())
+	mux.HandleFunc("GET /auth/callback", authn.Callback())
+	// Logout changes state, so it only accepts POST; a GET from a link or
+	// image on another site must not end the session.
+	mux.HandleFunc("POST /auth/logout", authn.Logout())
+
 	mux.HandleFunc("GET /healthz", api.Healthz())
 	mux.HandleFunc("POST /healthz", api.Healthz())
-	mux.Handle("POST /-/reload", api.ReloadHandler())
+	mux.Handle("POST /-/reload", operator(api.ReloadHandler()))
+	mux.Handle("POST /-/rollback", operator(api.RollbackHandler()))
 	mux.Handle("GET /metrics", api.Metrics())
 	mux.Handle("POST /metrics", api.Metrics())
 
 	apiMux := http.NewServeMux()
-	apiMux.Handle("GET /config", api.Config())
-	apiMux.HandleFunc("GET /heartbeats", api.HeartbeatsSum())
-	apiMux.HandleFunc("GET /receivers", api.ReceiversSum())
-	apiMux.HandleFunc("GET /status", api.StatusAll())
-	apiMux.HandleFunc("GET /status/{id}", api.Status())
-	apiMux.HandleFunc("GET /history", api.HistoryAll())
-	apiMux.HandleFunc("GET /history/{id}", api.HistoryByHeartbeat())
+	apiMux.Handle("GET /config", viewer(api.Config()))
+	apiMux.Handle("GET /heartbeats", viewer(api.HeartbeatsSum()))
+	apiMux.Handle("POST /heartbeats/{id}", admin(api.CreateHeartbeat()))
+	apiMux.Handle("PUT /heartbeats/{id}", admin(api.PutHeartbeat()))
+	apiMux.Handle("DELETE /heartbeats/{id}", admin(api.DeleteHeartbeat()))
+	apiMux.Handle("GET /receivers", viewer(api.ReceiversSum()))
+	apiMux.Handle("GET /receivers/{name}", admin(api.ReceiverConfig()))
+	apiMux.Handle("POST /receivers/{name}", admin(api.CreateReceiver()))
+	apiMux.Handle("PUT /receivers/{name}", admin(api.PutReceiver()))
+	apiMux.Handle("DELETE /receivers/{name}", admin(api.DeleteReceiver()))
+	apiMux.Handle("POST /receivers/{name}/test", operator(api.TestReceiver()))
+	apiMux.Handle("POST /templates/render", operator(api.RenderTemplate()))
+	apiMux.Handle("GET /notifications/failed", viewer(api.FailedNotifications()))
+	apiMux.Handle("POST /notifications/failed/{id}/replay", operator(api.ReplayNotification()))
+	apiMux.Handle("GET /status", viewer(api.StatusAll()))
+	apiMux.Handle("GET /status/{id}", viewer(api.Status()))
+	apiMux.Handle("GET /history", viewer(api.HistoryAll()))
+	apiMux.Handle("GET /history/{id}", viewer(api.HistoryByHeartbeat()))
 	apiMux.HandleFunc("GET /heartbeat/{id}", HistoryMiddleware(api.HistoryRecorder(), api.Heartbeat()))
 	apiMux.HandleFunc("POST /heartbeat/{id}", HistoryMiddleware(api.HistoryRecorder(), api.Heartbeat()))
-	apiMux.HandleFunc("GET /ws", api.WS())
+	apiMux.HandleFunc("GET /heartbeat/{id}/{token}", HistoryMiddleware(api.HistoryRecorder(), api.Heartbeat()))
+	apiMux.HandleFunc("POST /heartbeat/{id}/{token}", HistoryMiddleware(api.HistoryRecorder(), api.Heartbeat()))
+	apiMux.Handle("GET /heartbeat/{id}/payloads", viewer(api.HeartbeatPayloads()))
+	apiMux.Handle("GET /ws", viewer(api.WS()))
 
 	// Mount API under /api
 	mux.Handle("/api/", http.StripPrefix("/api", apiMux))
 
 	// SPA
-	mux.Handle("/", handler.SPA(spaContent, routePrefix))
+	mux.Handle("/", viewer(handler.SPA(spaContent, routePrefix)))
 
 	var h http.Handler = mux
 	if routePrefix != "" {
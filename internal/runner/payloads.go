@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultPayloadHistory is the number of payloads kept per heartbeat.
+	DefaultPayloadHistory = 10
+	// DefaultPayloadMaxBytes is the number of payload bytes stored per entry.
+	DefaultPayloadMaxBytes = 4096
+)
+
+// PayloadRecord is a stored heartbeat payload.
+type PayloadRecord struct {
+	Time        time.Time `json:"timestamp"`             // Receive time.
+	ContentType string    `json:"contentType,omitempty"` // Request Content-Type header.
+	Size        int       `json:"size"`                  // Original payload size in bytes.
+	Body        string    `json:"body"`                  // Payload body, capped at the configured size.
+	Truncated   bool      `json:"truncated,omitempty"`   // Whether Body was cut.
+	Error       string    `json:"error,omitempty"`       // Failure reason when the payload was rejected.
+}
+
+// payloadLog is a fixed-size ring of payload records.
+type payloadLog struct {
+	size     int             // Maximum number of records.
+	maxBytes int             // Maximum stored body bytes per record.
+	records  []PayloadRecord // Records in arrival order.
+}
+
+// SetPayloadLimits configures how many payloads are kept and how many bytes
+// of each are stored. Non-positive values fall back to the defaults.
+func (s *State) SetPayloadLimits(size, maxBytes int) {
+	if size <= 0 {
+		size = DefaultPayloadHistory
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultPayloadMaxBytes
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads.size = size
+	s.payloads.maxBytes = maxBytes
+	if over := len(s.payloads.records) - size; over > 0 {
+		s.payloads.records = append([]PayloadRecord(nil), s.payloads.records[over:]...)
+	}
+}
+
+// RecordPayload stores a payload, evicting the oldest record when full.
+func (s *State) RecordPayload(now time.Time, contentType, body, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log := &s.payloads
+	if log.size <= 0 {
+		log.size = DefaultPayloadHistory
+	}
+	if log.maxBytes <= 0 {
+		log.maxBytes = DefaultPayloadMaxBytes
+	}
+	rec := PayloadRecord{
+		Time:        now,
+		ContentType: contentType,
+		Size:        len(body),
+		Body:        body,
+		Error:       reason,
+	}
+	if len(body) > log.maxBytes {
+		rec.Body = trimPartialRune(body[:log.maxBytes])
+		rec.Truncated = true
+	}
+	if len(log.records) >= log.size {
+		copy(log.records, log.records[1:])
+		log.records = log.records[:len(log.records)-1]
+	}
+	log.records = append(log.records, rec)
+}
+
+// Payloads returns the stored payloads, newest first.
+func (s *State) Payloads() []PayloadRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]PayloadRecord, len(s.payloads.records))
+	for i, rec := range s.payloads.records {
+		out[len(out)-1-i] = rec
+	}
+	return out
+}
+
+// trimPartialRune drops a UTF-8 sequence left incomplete by cutting s at a
+// byte offset. Everything before it, including invalid or binary bytes, is
+// kept as is.
+func trimPartialRune(s string) string {
+	for i := len(s) - 1; i >= 0 && i >= len(s)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(s[i]) {
+			continue
+		}
+		if !utf8.FullRuneInString(s[i:]) {
+			return s[:i]
+		}
+		break
+	}
+	return s
+}
@@ -19,6 +19,8 @@ const (
 	StageLate
 	// StageMissing indicates the heartbeat is beyond the missing threshold.
 	StageMissing
+	// StageFailed indicates the last heartbeat reported a failure.
+	StageFailed
 )
 
 // String returns the stage identifier.
@@ -32,6 +34,8 @@ func (s Stage) String() string {
 		return "late"
 	case StageMissing:
 		return "missing"
+	case StageFailed:
+		return "failed"
 	default:
 		return "unknown"
 	}
@@ -50,6 +54,7 @@ type Sender interface {
 	Late(now time.Time, since time.Duration, payload string)
 	Missing(now time.Time, since time.Duration, payload string)
 	Recovered(now time.Time, payload string)
+	Failed(now time.Time, reason string, payload string)
 	Transition(now time.Time, from Stage, to Stage, since time.Duration)
 }
 
@@ -58,7 +63,9 @@ type State struct {
 	mu          sync.RWMutex       // Guards all fields.
 	lastSeen    time.Time          // Timestamp of last heartbeat.
 	lastPayload string             // Body of last heartbeat payload.
+	lastError   string             // Failure reason of the last heartbeat, if any.
 	stage       Stage              // Current stage.
+	payloads    payloadLog         // Recent payloads.
 	mailbox     chan HeartbeatType // Notifies when a heartbeat arrives.
 }
 
@@ -66,6 +73,7 @@ type State struct {
 type Snapshot struct {
 	LastSeen    time.Time // Timestamp of last heartbeat.
 	LastPayload string    // Body of last heartbeat payload.
+	LastError   string    // Failure reason of the last heartbeat, if any.
 	Stage       Stage     // Current stage.
 }
 
@@ -85,8 +93,22 @@ func (s *State) UpdateSeen(now time.Time, payload string) bool {
 	s.mu.Lock()
 	s.lastSeen = now
 	s.lastPayload = payload
+	s.lastError = ""
 	s.mu.Unlock()
-	return s.receiveSeen()
+	return s.receive(HeartbeatReceive)
+}
+
+// UpdateFailed records a failed heartbeat payload and notifies the runner.
+func (s *State) UpdateFailed(now time.Time, payload, reason string) bool {
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	s.mu.Lock()
+	s.lastSeen = now
+	s.lastPayload = payload
+	s.lastError = reason
+	s.mu.Unlock()
+	return s.receive(HeartbeatFail)
 }
 
 // Snapshot returns a copy of the current state.
@@ -97,6 +119,7 @@ func (s *State) Snapshot() Snapshot {
 	return Snapshot{
 		LastSeen:    s.lastSeen,
 		LastPayload: s.lastPayload,
+		LastError:   s.lastError,
 		Stage:       s.stage,
 	}
 }
@@ -115,6 +138,13 @@ func (s *State) MarkOK() {
 	s.mu.Unlock()
 }
 
+// MarkFailed sets the stage to failed.
+func (s *State) MarkFailed() {
+	s.mu.Lock()
+	s.stage = StageFailed
+	s.mu.Unlock()
+}
+
 // MarkLate sets the stage to late.
 func (s *State) MarkLate() {
 	s.mu.Lock()
@@ -128,12 +158,15 @@ func (s *State) Mailbox() <-chan HeartbeatType { return s.mailbox }
 // HeartbeatType represents a heartbeat event.
 type HeartbeatType int
 
-const HeartbeatReceive HeartbeatType = iota // HeartbeatReceive represents a received heartbeat.
+const (
+	HeartbeatReceive HeartbeatType = iota // HeartbeatReceive represents a received heartbeat.
+	HeartbeatFail                         // HeartbeatFail represents a heartbeat reporting a failure.
+)
 
-// receiveSeen enqueues a received heartbeat event.
-func (s *State) receiveSeen() bool {
+// receive enqueues a heartbeat event.
+func (s *State) receive(ev HeartbeatType) bool {
 	select {
-	case s.mailbox <- HeartbeatReceive:
+	case s.mailbox <- ev:
 		return true
 	default:
 		return false
@@ -186,11 +219,31 @@ func (s *State) onReceive(
 	sender.Transition(now, prev, StageOK, since)
 	s.MarkOK()
 	timer.Reset(interval)
-	if prev == StageMissing && alertOnRecovery {
+	if (prev == StageMissing || prev == StageFailed) && alertOnRecovery {
 		sender.Recovered(now, snap.LastPayload)
 	}
 }
 
+// onFail handles a failed heartbeat event. The alert is sent only when
+// entering the failed stage; the check timer keeps running so a job that
+// stops reporting still goes late and missing.
+func (s *State) onFail(
+	timer *stageTimer,
+	sender Sender,
+	interval time.Duration,
+	now time.Time,
+) {
+	snap := s.Snapshot()
+	prev := snap.Stage
+	since := now.Sub(snap.LastSeen)
+	sender.Transition(now, prev, StageFailed, since)
+	s.MarkFailed()
+	timer.Reset(interval)
+	if prev != StageFailed {
+		sender.Failed(now, snap.LastError, snap.LastPayload)
+	}
+}
+
 // Run executes the periodic runner loop until ctx is canceled.
 func Run(ctx context.Context, state *State, cfg Config, sender Sender, logger *slog.Logger) {
 	var timer stageTimer
@@ -206,6 +259,9 @@ func Run(ctx context.Context, state *State, cfg Config, sender Sender, logger *s
 			case HeartbeatReceive:
 				now := time.Now().UTC()
 				state.onReceive(&timer, sender, cfg.CheckInterval, cfg.AlertOnRecovery, now)
+			case HeartbeatFail:
+				now := time.Now().UTC()
+				state.onFail(&timer, sender, cfg.CheckInterval, now)
 			}
 
 		case <-timer.C():
@@ -217,7 +273,7 @@ func Run(ctx context.Context, state *State, cfg Config, sender Sender, logger *s
 			}
 			since := now.Sub(snap.LastSeen)
 			switch snap.Stage {
-			case StageOK: // state was ok, change it late
+			case StageOK, StageFailed: // state was ok or failed, change it late
 				state.enterLate(&timer, sender, snap, now, since, cfg.LateAfter, cfg.AlertOnLate)
 			case StageLate: // state was late, change it missing
 				state.enterMissing(&timer, sender, snap, now, since)
@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSender struct {
+	calls []string
+}
+
+func (r *recordingSender) Late(time.Time, time.Duration, string) {
+	r.calls = append(r.calls, "late")
+}
+
+func (r *recordingSender) Missing(time.Time, time.Duration, string) {
+	r.calls = append(r.calls, "missing")
+}
+
+func (r *recordingSender) Recovered(time.Time, string) {
+	r.calls = append(r.calls, "recovered")
+}
+
+func (r *recordingSender) Failed(_ time.Time, reason string, _ string) {
+	r.calls = append(r.calls, "failed:"+reason)
+}
+
+func (r *recordingSender) Transition(_ time.Time, from Stage, to Stage, _ time.Duration) {
+	r.calls = append(r.calls, from.String()+"->"+to.String())
+}
+
+func TestStateFailure(t *testing.T) {
+	t.Parallel()
+
+	t.Run("failure alerts once and recovery clears it", func(t *testing.T) {
+		t.Parallel()
+
+		state := NewState()
+		sender := &recordingSender{}
+		var timer stageTimer
+		defer timer.Stop()
+		now := time.Now()
+
+		require.True(t, state.UpdateFailed(now, "{}", "bad payload"))
+		require.Equal(t, HeartbeatFail, <-state.Mailbox())
+		state.onFail(&timer, sender, time.Minute, now)
+
+		require.True(t, state.UpdateFailed(now, "{}", "bad payload"))
+		<-state.Mailbox()
+		state.onFail(&timer, sender, time.Minute, now)
+
+		snap := state.Snapshot()
+		assert.Equal(t, StageFailed, snap.Stage)
+		assert.Equal(t, "bad payload", snap.LastError)
+		assert.True(t, timer.active, "check timer keeps running while failed")
+
+		require.True(t, state.UpdateSeen(now, "{}"))
+		require.Equal(t, HeartbeatReceive, <-state.Mailbox())
+		state.onReceive(&timer, sender, time.Minute, true, now)
+
+		assert.Equal(t, []string{
+			"never->failed",
+			"failed:bad payload",
+			"failed->failed",
+			"failed->ok",
+			"recovered",
+		}, sender.calls)
+		assert.Empty(t, state.Snapshot().LastError)
+	})
+}
+
+func TestStatePayloads(t *testing.T) {
+	t.Parallel()
+
+	t.Run("keeps the newest payloads first", func(t *testing.T) {
+		t.Parallel()
+
+		state := NewState()
+		state.SetPayloadLimits(2, 0)
+		now := time.Now()
+		state.RecordPayload(now, "text/plain", "one", "")
+		state.RecordPayload(now.Add(time.Second), "text/plain", "two", "")
+		state.RecordPayload(now.Add(2*time.Second), "application/json", "three", "bad payload")
+
+		payloads := state.Payloads()
+		require.Len(t, payloads, 2)
+		assert.Equal(t, "three", payloads[0].Body)
+		assert.Equal(t, "application/json", payloads[0].ContentType)
+		assert.Equal(t, "bad payload", payloads[0].Error)
+		assert.Equal(t, "two", payloads[1].Body)
+	})
+
+	t.Run("truncates oversized bodies", func(t *testing.T) {
+		t.Parallel()
+
+		state := NewState()
+		state.SetPayloadLimits(0, 4)
+		state.RecordPayload(time.Now(), "", "abcdef", "")
+
+		payloads := state.Payloads()
+		require.Len(t, payloads, 1)
+		assert.Equal(t, "abcd", payloads[0].Body)
+		assert.Equal(t, 6, payloads[0].Size)
+		assert.True(t, payloads[0].Truncated)
+	})
+
+	t.Run("truncation drops only a split trailing rune", func(t *testing.T) {
+		t.Parallel()
+
+		state := NewState()
+		state.SetPayloadLimits(0, 5)
+		state.RecordPayload(time.Now(), "", "\xff\x00ab\xc3\xa9", "")
+		state.RecordPayload(time.Now(), "", "\xff\x00abc\xa9", "")
+
+		payloads := state.Payloads()
+		require.Len(t, payloads, 2)
+		assert.Equal(t, "\xff\x00abc", payloads[0].Body, "invalid bytes are kept")
+		assert.Equal(t, "\xff\x00ab", payloads[1].Body, "the partial é is dropped")
+	})
+
+	t.Run("shrinking the limit drops the oldest payloads", func(t *testing.T) {
+		t.Parallel()
+
+		state := NewState()
+		now := time.Now()
+		for _, body := range []string{"a", "b", "c"} {
+			state.RecordPayload(now, "", body, "")
+		}
+		state.SetPayloadLimits(1, 0)
+
+		payloads := state.Payloads()
+		require.Len(t, payloads, 1)
+		assert.Equal(t, "c", payloads[0].Body)
+	})
+}
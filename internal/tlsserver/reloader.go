@@ -0,0 +1,186 @@
+// Package tlsserver serves HTTPS with certificates that are reloaded from
+// disk when they change, and optionally verifies client certificates.
+package tlsserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/containeroo/heartbeats/internal/logging"
+)
+
+// DefaultReloadInterval is how often certificate files are checked for changes.
+const DefaultReloadInterval = 10 * time.Second
+
+// Options configures the served certificate and client verification.
+type Options struct {
+	CertFile     string             // PEM certificate chain.
+	KeyFile      string             // PEM private key.
+	ClientCAFile string             // PEM CA bundle for client certificates; empty disables mTLS.
+	ClientAuth   tls.ClientAuthType // Client certificate policy when ClientCAFile is set.
+}
+
+// fileStamp identifies a version of a file on disk.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Reloader holds the current certificate and client CA pool.
+type Reloader struct {
+	opts   Options
+	logger *slog.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	stamps    map[string]fileStamp
+}
+
+// NewReloader loads the configured files. It fails when they cannot be read.
+func NewReloader(opts Options, logger *slog.Logger) (*Reloader, error) {
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, errors.New("certificate and key file are required")
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	r := &Reloader{opts: opts, logger: logger}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// files returns the watched paths.
+func (r *Reloader) files() []string {
+	files := []string{r.opts.CertFile, r.opts.KeyFile}
+	if r.opts.ClientCAFile != "" {
+		files = append(files, r.opts.ClientCAFile)
+	}
+	return files
+}
+
+// Reload reads the certificate, key, and client CA bundle from disk.
+// The previous material stays active when loading fails.
+func (r *Reloader) Reload() error {
+	stamps, err := stat(r.files())
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("client CA %q contains no certificates", r.opts.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCAs = pool
+	r.stamps = stamps
+	return nil
+}
+
+// changed reports whether any watched file differs from the loaded version.
+func (r *Reloader) changed() bool {
+	stamps, err := stat(r.files())
+	if err != nil {
+		// A missing file is usually a secret update in progress; retry later.
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for path, stamp := range stamps {
+		if r.stamps[path] != stamp {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch polls the files every interval and reloads them after a change,
+// until ctx is canceled.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				r.logger.Error("TLS reload failed",
+					"event", logging.EventTLSReloadFailed.String(),
+					"err", err,
+				)
+				continue
+			}
+			r.logger.Info("TLS certificates reloaded",
+				"event", logging.EventTLSReloaded.String(),
+				"cert_file", r.opts.CertFile,
+			)
+		}
+	}
+}
+
+// TLSConfig returns a server config that always uses the current material.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: r.configForClient,
+	}
+}
+
+// configForClient builds the per-connection config from the current material.
+func (r *Reloader) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*r.cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.clientCAs != nil {
+		cfg.ClientCAs = r.clientCAs
+		cfg.ClientAuth = r.opts.ClientAuth
+	}
+	return cfg, nil
+}
+
+// stat returns the current stamp of each path.
+func stat(paths []string) (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, path := range paths {
+		// Stat follows symlinks, so Kubernetes secret swaps are noticed.
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps, nil
+}
@@ -0,0 +1,185 @@
+package tlsserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issue creates a certificate for cn, signed by parent or self-signed.
+func issue(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		DNSNames:              []string{cn},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+// writePair writes cert and key as PEM files into dir.
+func writePair(t *testing.T, dir, name string, cert *x509.Certificate, key *ecdsa.PrivateKey) (string, string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+// serve starts an HTTPS test server backed by r.
+func serve(t *testing.T, r *Reloader) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(req.TLS.PeerCertificates) > 0 {
+			_, _ = w.Write([]byte(req.TLS.PeerCertificates[0].Subject.CommonName))
+		}
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // Rejected handshakes are expected.
+	srv.TLS = r.TLSConfig()
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// servedCN returns the common name of the certificate the server presents.
+func servedCN(t *testing.T, url string, roots *x509.CertPool) string {
+	t.Helper()
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close() // nolint:errcheck
+	return resp.TLS.PeerCertificates[0].Subject.CommonName
+}
+
+func TestReloader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reloads changed certificates", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		first, firstKey := issue(t, "first.example", nil, nil, true)
+		certFile, keyFile := writePair(t, dir, "server", first, firstKey)
+
+		r, err := NewReloader(Options{CertFile: certFile, KeyFile: keyFile}, nil)
+		require.NoError(t, err)
+		srv := serve(t, r)
+
+		roots := x509.NewCertPool()
+		roots.AddCert(first)
+		assert.Equal(t, "first.example", servedCN(t, srv.URL, roots))
+		assert.False(t, r.changed())
+
+		second, secondKey := issue(t, "second.example", nil, nil, true)
+		writePair(t, dir, "server", second, secondKey)
+		// Ensure a different stamp even on filesystems with coarse mtimes.
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(certFile, future, future))
+		require.True(t, r.changed())
+		require.NoError(t, r.Reload())
+
+		roots.AddCert(second)
+		assert.Equal(t, "second.example", servedCN(t, srv.URL, roots))
+	})
+
+	t.Run("keeps previous certificate on invalid files", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		cert, key := issue(t, "server.example", nil, nil, true)
+		certFile, keyFile := writePair(t, dir, "server", cert, key)
+
+		r, err := NewReloader(Options{CertFile: certFile, KeyFile: keyFile}, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0o600))
+		require.Error(t, r.Reload())
+
+		srv := serve(t, r)
+		roots := x509.NewCertPool()
+		roots.AddCert(cert)
+		assert.Equal(t, "server.example", servedCN(t, srv.URL, roots))
+	})
+
+	t.Run("requires client certificates", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		ca, caKey := issue(t, "ca", nil, nil, true)
+		server, serverKey := issue(t, "server.example", ca, caKey, false)
+		client, clientKey := issue(t, "backup-job", ca, caKey, false)
+		certFile, keyFile := writePair(t, dir, "server", server, serverKey)
+		clientCert, clientKeyFile := writePair(t, dir, "client", client, clientKey)
+		caFile, _ := writePair(t, dir, "ca", ca, caKey)
+
+		r, err := NewReloader(Options{
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+			ClientCAFile: caFile,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		}, nil)
+		require.NoError(t, err)
+		srv := serve(t, r)
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca)
+		anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+		_, err = anonymous.Get(srv.URL)
+		require.Error(t, err)
+
+		pair, err := tls.LoadX509KeyPair(clientCert, clientKeyFile)
+		require.NoError(t, err)
+		authenticated := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: []tls.Certificate{pair},
+		}}}
+		resp, err := authenticated.Get(srv.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close() // nolint:errcheck
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("missing files", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewReloader(Options{CertFile: "missing.crt", KeyFile: "missing.key"}, nil)
+		require.Error(t, err)
+
+		_, err = NewReloader(Options{}, nil)
+		require.EqualError(t, err, "certificate and key file are required")
+	})
+}
@@ -0,0 +1,61 @@
+package tlsserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Timeouts match the plain HTTP server started by httpgrace.
+const (
+	readHeaderTimeout = 10 * time.Second
+	writeTimeout      = 15 * time.Second
+	idleTimeout       = 60 * time.Second
+	shutdownTimeout   = 10 * time.Second
+)
+
+// Run serves handler over TLS on listenAddr until ctx is canceled, then
+// shuts down gracefully. Certificates come from tlsConfig, so they can change
+// while the server is running.
+func Run(ctx context.Context, listenAddr string, handler http.Handler, tlsConfig *tls.Config, logger *slog.Logger) error {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	server := &http.Server{
+		Addr:              listenAddr,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: readHeaderTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("starting server", "listenAddr", server.Addr, "tls", true)
+		// Empty paths: the certificate is provided by tlsConfig.
+		serveErr <- server.ListenAndServeTLS("", "")
+	}()
+
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down server", "cause", context.Cause(ctx))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		// Force close to unblock ListenAndServeTLS if graceful shutdown times out.
+		_ = server.Close()
+		return err
+	}
+	return nil
+}
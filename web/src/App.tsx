@@ -1,7 +1,9 @@
+import { testReceiver } from "./api";
 import { AppShell } from "./components/shell/AppShell";
 import { HeartbeatsView } from "./components/views/HeartbeatsView";
 import { HistoryView } from "./components/views/HistoryView";
 import { ReceiversView } from "./components/views/ReceiversView";
+import { TemplatesView } from "./components/views/TemplatesView";
 import { useConfig } from "./hooks/data/useConfig";
 import { useDashboardData } from "./hooks/data/useDashboardData";
 import { useFilters } from "./hooks/ui/useFilters";
@@ -78,6 +80,23 @@ export default function App() {
     }
   }
 
+  async function handleTestReceiver(id: string) {
+    try {
+      const result = await testReceiver(id);
+      const failed = result.targets.filter((target) => !target.ok);
+      if (failed.length === 0) {
+        showToast(`Test notification sent to ${id}`);
+        return;
+      }
+      const details = failed
+        .map((target) => `${target.type} ${target.destination}: ${target.error}`)
+        .join("; ");
+      showToast(`Test failed for ${id}: ${details}`, 6000);
+    } catch (error) {
+      showToast(error instanceof Error ? error.message : "Test failed");
+    }
+  }
+
   const footerText = useFooterText(runtime);
 
   const isRefreshing = heartbeatsLoading || receiversLoading || historyLoading;
@@ -132,6 +151,7 @@ export default function App() {
           items={receivers}
           query={receiverQuery}
           onQueryChange={setReceiverQuery}
+          onTest={handleTestReceiver}
         />
       ) : null}
       {view === "history" ? (
@@ -141,6 +161,9 @@ export default function App() {
           onQueryChange={setHistoryQuery}
         />
       ) : null}
+      {view === "templates" ? (
+        <TemplatesView heartbeats={heartbeats} receivers={receivers} />
+      ) : null}
     </AppShell>
   );
 }
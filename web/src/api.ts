@@ -1,4 +1,11 @@
-import type { Heartbeat, HistoryEvent, Receiver } from "./types";
+import type {
+  Heartbeat,
+  HistoryEvent,
+  PayloadRecord,
+  Receiver,
+  ReceiverTestResult,
+  RenderRequest,
+} from "./types";
 import { withBasePath } from "./utils/basePath";
 
 /** request dispatches a JSON request to the backend and unwraps responses. */
@@ -11,6 +18,19 @@ async function request<T>(path: string, init?: RequestInit): Promise<T> {
     ...init,
   });
 
+  if (res.status === 401) {
+    // Expired SSO sessions: the server tells us where to log in again.
+    const loginURL = res.headers.get("X-Login-URL");
+    if (loginURL) {
+      const target = new URL(loginURL, window.location.origin);
+      target.searchParams.set(
+        "redirect",
+        window.location.pathname + window.location.search,
+      );
+      window.location.assign(target.toString());
+    }
+  }
+
   if (!res.ok) {
     let message = res.statusText;
     try {
@@ -40,11 +60,33 @@ export async function listReceivers(): Promise<Receiver[]> {
   return request("/api/receivers");
 }
 
+/** testReceiver sends a test notification through every target of a receiver. */
+export async function testReceiver(name: string): Promise<ReceiverTestResult> {
+  return request(`/api/receivers/${encodeURIComponent(name)}/test`, {
+    method: "POST",
+  });
+}
+
+/** renderTemplate renders a template preview; template errors are thrown. */
+export async function renderTemplate(
+  body: RenderRequest,
+): Promise<{ output: string }> {
+  return request("/api/templates/render", {
+    method: "POST",
+    body: JSON.stringify(body),
+  });
+}
+
 /** listHistory returns the recorded history events. */
 export async function listHistory(): Promise<HistoryEvent[]> {
   return request("/api/history");
 }
 
+/** listPayloads returns the recent payloads of a heartbeat, newest first. */
+export async function listPayloads(id: string): Promise<PayloadRecord[]> {
+  return request(`/api/heartbeat/${encodeURIComponent(id)}/payloads`);
+}
+
 /** reloadConfig triggers a server-side config reload. */
 export async function reloadConfig(): Promise<void> {
   return request("/-/reload", { method: "POST" });
@@ -1,10 +1,11 @@
 import { useEffect, useRef, useState } from "react";
-import type { Heartbeat } from "../../types";
+import { listPayloads } from "../../api";
+import type { Heartbeat, PayloadRecord } from "../../types";
 import { formatDateTime } from "../../utils/format";
 import { heartbeatStatusClass, heartbeatStatusLabel } from "../../utils/status";
 import { buildHeartbeatURL } from "../../utils/url";
 
-/** HeartbeatDetails shows metadata, receivers, and a copyable URL for a heartbeat. */
+/** HeartbeatDetails shows metadata, receivers, recent payloads, and a copyable URL for a heartbeat. */
 export function HeartbeatDetails({
   hb,
   siteURL,
@@ -17,6 +18,21 @@ export function HeartbeatDetails({
   const url = buildHeartbeatURL(hb, siteURL);
   const [copied, setCopied] = useState(false);
   const timeoutRef = useRef<number | null>(null);
+  const [payloads, setPayloads] = useState<PayloadRecord[] | null>(null);
+
+  useEffect(() => {
+    let cancelled = false;
+    listPayloads(hb.id)
+      .then((items) => {
+        if (!cancelled) setPayloads(items);
+      })
+      .catch(() => {
+        if (!cancelled) setPayloads([]);
+      });
+    return () => {
+      cancelled = true;
+    };
+  }, [hb.id, hb.lastBump]);
 
   useEffect(() => {
     return () => {
@@ -96,6 +112,38 @@ export function HeartbeatDetails({
           </button>
         </div>
       </div>
+      <div className="details-span">
+        <p className="eyebrow label">Payloads</p>
+        {payloads === null && <p className="detail value subtle">loading…</p>}
+        {payloads !== null && payloads.length === 0 && (
+          <p className="detail value">—</p>
+        )}
+        {payloads !== null && payloads.length > 0 && (
+          <div className="payload-list">
+            {payloads.map((p, idx) => (
+              <details className="detail-toggle" key={`${p.timestamp}-${idx}`}>
+                <summary>
+                  <span className="detail">
+                    {formatDateTime(p.timestamp)}
+                    <span className="subtle">
+                      {" "}
+                      · {p.contentType || "unknown type"} · {p.size} B
+                      {p.truncated ? " (truncated)" : ""}
+                    </span>
+                    {p.error ? (
+                      <span className="payload-error"> · {p.error}</span>
+                    ) : null}
+                  </span>
+                  <span className="detail-chevron" aria-hidden="true" />
+                </summary>
+                <pre className="payload-body mono small">
+                  {p.body || "(empty)"}
+                </pre>
+              </details>
+            ))}
+          </div>
+        )}
+      </div>
     </div>
   );
 }
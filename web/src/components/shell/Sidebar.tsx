@@ -1,5 +1,5 @@
 /** VIEWS enumerates the available top-level views. */
-const VIEWS = ["heartbeats", "receivers", "history"] as const;
+const VIEWS = ["heartbeats", "receivers", "history", "templates"] as const;
 
 /** View represents a permitted navigation target. */
 type View = (typeof VIEWS)[number];
@@ -1,4 +1,4 @@
-import { useMemo } from "react";
+import { useMemo, useState } from "react";
 import type { Receiver } from "../../types";
 import { formatDateTime, formatRecentTimestamp } from "../../utils/format";
 import { receiverStatusClass, receiverStatusLabel } from "../../utils/status";
@@ -9,12 +9,24 @@ export function ReceiversView({
   items,
   query,
   onQueryChange,
+  onTest,
 }: {
   items: Receiver[];
   query: string;
   onQueryChange: (value: string) => void;
+  onTest: (id: string) => Promise<void>;
 }) {
   const tick = useRecentTimer(items.map((rv) => rv.lastSent));
+  const [testing, setTesting] = useState<string | null>(null);
+
+  async function handleTest(id: string) {
+    setTesting(id);
+    try {
+      await onTest(id);
+    } finally {
+      setTesting(null);
+    }
+  }
 
   const filtered = useMemo(() => {
     const needle = query.trim().toLowerCase();
@@ -43,6 +55,7 @@ export function ReceiversView({
           <div>Destination</div>
           <div>Last sent</div>
           <div>Status</div>
+          <div />
         </div>
         <div className="table-body">
           {filtered.map((rv) => (
@@ -65,6 +78,17 @@ export function ReceiversView({
                 </span>
                 {rv.lastErr ? <p className="subtle">{rv.lastErr}</p> : null}
               </div>
+              <div>
+                <button
+                  className="btn subtle"
+                  type="button"
+                  disabled={testing !== null}
+                  onClick={() => void handleTest(rv.id)}
+                  title="Send a test notification to every target of this receiver"
+                >
+                  {testing === rv.id ? "Sending…" : "Test"}
+                </button>
+              </div>
             </div>
           ))}
         </div>
@@ -0,0 +1,143 @@
+import { useMemo, useState } from "react";
+import { renderTemplate } from "../../api";
+import type { Heartbeat, Receiver } from "../../types";
+
+/** STATUSES lists the notification statuses a template can be rendered for. */
+const STATUSES = ["late", "missing", "recovered", "failed"] as const;
+
+/** TemplatesView renders a template editor with a live preview. */
+export function TemplatesView({
+  heartbeats,
+  receivers,
+}: {
+  heartbeats: Heartbeat[];
+  receivers: Receiver[];
+}) {
+  const [template, setTemplate] = useState("");
+  const [receiver, setReceiver] = useState("");
+  const [heartbeat, setHeartbeat] = useState("");
+  const [status, setStatus] = useState<string>("late");
+  const [subject, setSubject] = useState(false);
+  const [text, setText] = useState(false);
+  const [output, setOutput] = useState<string | null>(null);
+  const [error, setError] = useState<string | null>(null);
+  const [rendering, setRendering] = useState(false);
+
+  const receiverIDs = useMemo(
+    () => Array.from(new Set(receivers.map((rv) => rv.id))).sort(),
+    [receivers],
+  );
+
+  async function handleRender() {
+    setRendering(true);
+    try {
+      const result = await renderTemplate({
+        template: template || undefined,
+        receiver: receiver || undefined,
+        heartbeat: heartbeat || undefined,
+        status,
+        subject,
+        target: receiver && text ? "email" : undefined,
+        text: receiver && text ? true : undefined,
+      });
+      setOutput(result.output);
+      setError(null);
+    } catch (err) {
+      setOutput(null);
+      setError(err instanceof Error ? err.message : "Render failed");
+    } finally {
+      setRendering(false);
+    }
+  }
+
+  return (
+    <section className="panel">
+      <div className="panel-controls">
+        <label className="search">
+          <span>Receiver</span>
+          <select
+            value={receiver}
+            onChange={(event) => setReceiver(event.target.value)}
+          >
+            <option value="">none (template only)</option>
+            {receiverIDs.map((id) => (
+              <option key={id} value={id}>
+                {id}
+              </option>
+            ))}
+          </select>
+        </label>
+        <label className="search">
+          <span>Heartbeat</span>
+          <select
+            value={heartbeat}
+            onChange={(event) => setHeartbeat(event.target.value)}
+          >
+            <option value="">sample data</option>
+            {heartbeats.map((hb) => (
+              <option key={hb.id} value={hb.id}>
+                {hb.id}
+              </option>
+            ))}
+          </select>
+        </label>
+        <label className="search">
+          <span>Status</span>
+          <select
+            value={status}
+            onChange={(event) => setStatus(event.target.value)}
+          >
+            {STATUSES.map((value) => (
+              <option key={value} value={value}>
+                {value}
+              </option>
+            ))}
+          </select>
+        </label>
+        <label className="checkbox">
+          <input
+            type="checkbox"
+            checked={subject}
+            onChange={(event) => setSubject(event.target.checked)}
+          />
+          <span>Subject</span>
+        </label>
+        <label className="checkbox">
+          <input
+            type="checkbox"
+            checked={text}
+            disabled={!receiver || subject}
+            onChange={(event) => setText(event.target.checked)}
+          />
+          <span>Plain-text email</span>
+        </label>
+      </div>
+
+      <div className="template-editor">
+        <textarea
+          className="template-input"
+          value={template}
+          onChange={(event) => setTemplate(event.target.value)}
+          placeholder={
+            receiver
+              ? `Leave empty to render the configured ${subject ? "subject" : text ? "plain-text email template" : "template"} of ${receiver}`
+              : "{{ .Title }} is {{ .Status }}"
+          }
+          spellCheck={false}
+        />
+        <button
+          className="btn"
+          type="button"
+          onClick={() => void handleRender()}
+          disabled={rendering || (!template && !receiver && !subject)}
+        >
+          {rendering ? "Rendering…" : "Render"}
+        </button>
+        {error ? (
+          <pre className="payload-body payload-error">{error}</pre>
+        ) : null}
+        {output !== null ? <pre className="payload-body">{output}</pre> : null}
+      </div>
+    </section>
+  );
+}
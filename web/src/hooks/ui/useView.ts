@@ -1,7 +1,7 @@
 import { useEffect, useState } from "react";
 
 /** VIEWS enumerates the supported dashboard screens. */
-const VIEWS = ["heartbeats", "receivers", "history"] as const;
+const VIEWS = ["heartbeats", "receivers", "history", "templates"] as const;
 /** View represents one of the dashboard screens. */
 type View = (typeof VIEWS)[number];
 
@@ -22,6 +22,43 @@ export type Receiver = {
   lastErr?: string | null;
 };
 
+/** ReceiverTestResult mirrors the outcome of a test notification. */
+export type ReceiverTestResult = {
+  receiver: string;
+  ok: boolean;
+  targets: {
+    type: string;
+    destination: string;
+    ok: boolean;
+    statusCode?: number;
+    error?: string;
+    durationMs: number;
+  }[];
+};
+
+/** RenderRequest selects the template and data of a template preview. */
+export type RenderRequest = {
+  template?: string;
+  receiver?: string;
+  target?: "webhook" | "email";
+  index?: number;
+  subject?: boolean;
+  text?: boolean;
+  heartbeat?: string;
+  status?: string;
+  payload?: string;
+};
+
+/** PayloadRecord mirrors a stored heartbeat payload. */
+export type PayloadRecord = {
+  timestamp: string;
+  contentType?: string;
+  size: number;
+  body: string;
+  truncated?: boolean;
+  error?: string;
+};
+
 /** HistoryEvent mirrors the backend history event payload. */
 export type HistoryEvent = {
   timestamp: string;
@@ -10,6 +10,7 @@ export function heartbeatStatusClass(status?: string): string {
     case "ok":
       return "status-pill status-ok";
     case "missing":
+    case "failed":
       return "status-pill status-missing";
     case "late":
       return "status-pill status-late";